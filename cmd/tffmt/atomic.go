@@ -0,0 +1,56 @@
+package tffmt
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// pendingWrite is a formatted file held back until the whole run succeeds
+type pendingWrite struct {
+	path    string
+	content []byte
+	perm    fs.FileMode
+}
+
+// pending collects the writes of an -atomic run
+var pending struct {
+	sync.Mutex
+	writes []pendingWrite
+}
+
+// deferWrite holds back writing content to path until commitWrites
+func deferWrite(path string, content []byte, perm fs.FileMode) {
+	pending.Lock()
+	defer pending.Unlock()
+	pending.writes = append(pending.writes, pendingWrite{path, content, perm})
+}
+
+// finishAtomic writes the held back files of an -atomic run, or none of
+// them when any file or path failed or the run was interrupted
+func finishAtomic(exit *int) {
+	pending.Lock()
+	defer pending.Unlock()
+	writes := pending.writes
+	pending.writes = nil
+
+	if len(report.errors) > 0 || *exit != 0 {
+		if len(writes) > 0 {
+			fmt.Fprintf(stderr, "tffmt: not writing %d files because of errors\n", len(writes))
+		}
+		return
+	}
+	if interrupted.Load() {
+		if len(writes) > 0 {
+			fmt.Fprintf(stderr, "tffmt: not writing %d files because the run was interrupted\n", len(writes))
+		}
+		return
+	}
+	for _, w := range writes {
+		if err := os.WriteFile(w.path, w.content, w.perm); err != nil {
+			fmt.Fprintln(stderr, "tffmt:", err)
+			*exit = 1
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestAtomicWrites verifies -atomic writes nothing when any file fails to
+// parse, and everything when none does
+func TestAtomicWrites(t *testing.T) {
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	tests := []struct {
+		name        string
+		broken      bool
+		wantWritten bool
+	}{
+		{"all files parse", false, true},
+		{"one broken file", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			files := []string{"a.tf", "c.tf"}
+			for _, name := range files {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(unformatted), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.broken {
+				if err := os.WriteFile(filepath.Join(tmpDir, "b.tf"), []byte("resource \"broken\" {"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			origReport := report
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+				report = origReport
+			}()
+
+			cfg = config.NewConfig()
+			cfg.List = false
+			cfg.Atomic = true
+			formatterInst = formatter.New(cfg)
+			report = &runReport{}
+
+			exit := 0
+			if err := walkDir(tmpDir, &exit); err != nil {
+				t.Fatal(err)
+			}
+			finishAtomic(&exit)
+
+			for _, name := range files {
+				output, err := os.ReadFile(filepath.Join(tmpDir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if written := string(output) != unformatted; written != tt.wantWritten {
+					t.Errorf("%s written = %v, want %v", name, written, tt.wantWritten)
+				}
+			}
+		})
+	}
+}
+
+// TestAtomicPathErrors verifies -atomic writes nothing when a path given on
+// the command line fails, even though every file formatted
+func TestAtomicPathErrors(t *testing.T) {
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	tmpDir := t.TempDir()
+	mainPath := filepath.Join(tmpDir, "main.tf")
+	if err := os.WriteFile(mainPath, []byte(unformatted), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	var out, errOut bytes.Buffer
+	args := []string{"-atomic", tmpDir, filepath.Join(tmpDir, "missing")}
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 2 {
+		t.Errorf("Run() = %d, want 2 (stderr: %s)", exit, errOut.String())
+	}
+	output, err := os.ReadFile(mainPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != unformatted {
+		t.Errorf("main.tf = %q, want it unwritten", output)
+	}
+	if !strings.Contains(errOut.String(), "not writing 1 files because of errors") {
+		t.Errorf("stderr = %q, want it to say the files aren't written", errOut.String())
+	}
+}
@@ -0,0 +1,65 @@
+package tffmt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// blockCounts holds the attribute count of every top-level block seen
+// under -count-attributes
+var blockCounts []blockCount
+
+// blockCount is the number of attributes in a single top-level block
+type blockCount struct {
+	path       string
+	block      string
+	attributes int
+}
+
+// recordAttributeCounts counts the attributes of each top-level block in
+// content, including those of nested blocks
+func recordAttributeCounts(path string, content []byte) {
+	file, diags := hclwrite.ParseConfig(content, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return
+	}
+	for _, block := range file.Body().Blocks() {
+		name := strings.Join(append([]string{block.Type()}, block.Labels()...), ".")
+		blockCounts = append(blockCounts, blockCount{
+			path:       path,
+			block:      name,
+			attributes: countAttributes(block.Body()),
+		})
+	}
+}
+
+// countAttributes returns the number of attributes in body and its nested blocks
+func countAttributes(body *hclwrite.Body) int {
+	n := len(body.Attributes())
+	for _, block := range body.Blocks() {
+		n += countAttributes(block.Body())
+	}
+	return n
+}
+
+// attributeCountLines returns the blocks with the most attributes first,
+// limited to the top n when n is positive
+func attributeCountLines(n int) []string {
+	counts := append([]blockCount(nil), blockCounts...)
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].attributes > counts[j].attributes
+	})
+	if n > 0 && len(counts) > n {
+		counts = counts[:n]
+	}
+
+	lines := make([]string, 0, len(counts))
+	for _, c := range counts {
+		lines = append(lines, fmt.Sprintf("%s:%s: %d attributes", c.path, c.block, c.attributes))
+	}
+	return lines
+}
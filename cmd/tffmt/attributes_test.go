@@ -0,0 +1,79 @@
+package tffmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestCountAttributes verifies the block with the most attributes is reported first
+func TestCountAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"small.tf": "variable \"name\" {\n  type = string\n}\n",
+		"large.tf": `resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+
+  root_block_device {
+    volume_size = 20
+    volume_type = "gp3"
+  }
+}
+
+output "ip" {
+  value       = aws_instance.web.public_ip
+  description = "public ip"
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origCounts := blockCounts
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		blockCounts = origCounts
+	}()
+
+	cfg = config.NewConfig()
+	cfg.Write = false
+	cfg.List = false
+	cfg.CountAttributes = true
+	formatterInst = formatter.New(cfg)
+	blockCounts = nil
+
+	exit := 0
+	if err := walkDir(tmpDir, &exit); err != nil {
+		t.Fatal(err)
+	}
+
+	large := filepath.Join(tmpDir, "large.tf")
+	expected := []string{
+		large + ":resource.aws_instance.web: 4 attributes",
+		large + ":output.ip: 2 attributes",
+	}
+	lines := attributeCountLines(2)
+	if len(lines) != len(expected) {
+		t.Fatalf("attributeCountLines(2) = %q, want %q", lines, expected)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], expected[i])
+		}
+	}
+
+	if all := attributeCountLines(0); len(all) != 3 {
+		t.Errorf("attributeCountLines(0) returned %d lines, want 3", len(all))
+	}
+}
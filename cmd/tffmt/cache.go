@@ -0,0 +1,152 @@
+package tffmt
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// checkCache remembers the git blob SHAs of files that were already
+// formatted, along with a hash of the configuration they were formatted
+// under, so unchanged files can be skipped on the next run without reading
+// or formatting them. A file formatted under other options is a miss.
+type checkCache struct {
+	path      string
+	blobs     map[string]string
+	mu        sync.Mutex
+	formatted map[cacheEntry]bool
+	hashes    map[*config.Config]string
+}
+
+// cacheEntry is a blob formatted under the configuration hashed to Config
+type cacheEntry struct {
+	Blob   string `json:"blob"`
+	Config string `json:"config"`
+}
+
+// cacheFile is the on-disk representation of a checkCache
+type cacheFile struct {
+	Entries []cacheEntry `json:"entries"`
+}
+
+// loadCheckCache reads the cache at path, if it exists, and looks up the
+// blob SHAs of the files tracked by the git repository in the current
+// directory.
+func loadCheckCache(path string) (*checkCache, error) {
+	blobs, err := gitBlobSHAs(".")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checkCache{
+		path:      path,
+		blobs:     blobs,
+		formatted: make(map[cacheEntry]bool),
+		hashes:    make(map[*config.Config]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for _, entry := range file.Entries {
+		c.formatted[entry] = true
+	}
+	return c, nil
+}
+
+// blobSHA returns the git blob SHA of path, if it is known
+func (c *checkCache) blobSHA(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	sha, ok := c.blobs[abs]
+	return sha, ok
+}
+
+// entry returns the cache entry of path under the configuration its
+// formatter uses, including that of a nested settings file
+func (c *checkCache) entry(path string) (cacheEntry, bool) {
+	sha, ok := c.blobSHA(path)
+	if !ok {
+		return cacheEntry{}, false
+	}
+	conf := cfg
+	if f, ok := formatterFor(path); ok {
+		if hclFmt, ok := f.(*formatter.Formatter); ok {
+			conf = hclFmt.Config
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, ok := c.hashes[conf]
+	if !ok {
+		data, err := json.Marshal(conf)
+		if err != nil {
+			return cacheEntry{}, false
+		}
+		sum := sha256.Sum256(data)
+		hash = hex.EncodeToString(sum[:])
+		c.hashes[conf] = hash
+	}
+	return cacheEntry{Blob: sha, Config: hash}, true
+}
+
+// hit reports whether path is known to be formatted already under the
+// current configuration
+func (c *checkCache) hit(path string) bool {
+	entry, ok := c.entry(path)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.formatted[entry]
+}
+
+// record remembers that path is formatted under the current configuration
+func (c *checkCache) record(path string) {
+	if entry, ok := c.entry(path); ok {
+		c.mu.Lock()
+		c.formatted[entry] = true
+		c.mu.Unlock()
+	}
+}
+
+// save writes the cache back to disk
+func (c *checkCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file := cacheFile{Entries: make([]cacheEntry, 0, len(c.formatted))}
+	for entry := range c.formatted {
+		file.Entries = append(file.Entries, entry)
+	}
+	slices.SortFunc(file.Entries, func(a, b cacheEntry) int {
+		return cmp.Or(cmp.Compare(a.Blob, b.Blob), cmp.Compare(a.Config, b.Config))
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0600)
+}
@@ -0,0 +1,234 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestCheckCacheHit verifies a file whose blob SHA is cached as formatted
+// is skipped without being formatted
+func TestCheckCacheHit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "tffmt-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(currentDir)
+
+	if _, err := gitOutput(".", "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+
+	formatted := "resource \"example\" \"test\" {\n  foo = bar\n}\n\n"
+	if err := os.WriteFile("main.tf", []byte(formatted), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitOutput(".", "add", "main.tf"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origCache := cache
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		cache = origCache
+	}()
+
+	cfg = config.NewConfig()
+	cfg.Check = true
+	formatterInst = formatter.New(cfg)
+
+	// First run: nothing cached yet, the file is formatted and recorded
+	cachePath := filepath.Join(tmpDir, "cache.json")
+	cache, err = loadCheckCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := processFile("main.tf")
+	if res.Cached || res.Changed || res.Err != nil {
+		t.Fatalf("first run: processFile() = %+v, want an uncached, unchanged result", res)
+	}
+	if err := cache.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Second run: the blob SHA is cached, so the file is skipped
+	cache, err = loadCheckCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = processFile("main.tf")
+	if !res.Cached {
+		t.Errorf("second run: processFile() cached = false, want true")
+	}
+	if res.Orig != nil {
+		t.Errorf("second run: file was read despite a cache hit")
+	}
+
+	// Formatting options the file wasn't cached under are a miss
+	cfg = config.NewConfig()
+	cfg.Check = true
+	cfg.SortInputs = true
+	formatterInst = formatter.New(cfg)
+	cache, err = loadCheckCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = processFile("main.tf")
+	if res.Cached {
+		t.Errorf("changed options: processFile() cached = true, want false")
+	}
+	cfg = config.NewConfig()
+	cfg.Check = true
+	formatterInst = formatter.New(cfg)
+
+	// Modifying the file on disk invalidates the index SHA
+	if err := os.WriteFile("main.tf", []byte("resource \"example\" \"test\" {foo = bar}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = loadCheckCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = processFile("main.tf")
+	if res.Cached || !res.Changed {
+		t.Errorf("modified file: processFile() = %+v, want an uncached, changed result", res)
+	}
+}
+
+// TestCheckCacheNestedSettings verifies a file cached under one .tffmt.yml
+// is checked again once a nested settings file changes how it's formatted
+func TestCheckCacheNestedSettings(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	if _, err := gitOutput(".", "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	unsorted := "resource \"example\" \"test\" {\n  zone = 1\n  ami  = 2\n}\n\n"
+	if err := os.MkdirAll("sub", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("sub/main.tf", []byte(unsorted), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitOutput(".", "add", "sub/main.tf"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origCache := cache
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		cache = origCache
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	args := []string{"-check", "-cache", filepath.Join(tmpDir, "cache.json"), "sub/main.tf"}
+	var out, errOut bytes.Buffer
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 0 {
+		t.Fatalf("first run: Run() = %d, want 0 (stderr: %s)", exit, errOut.String())
+	}
+
+	if err := os.WriteFile("sub/.tffmt.yml", []byte("sort-inputs: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	errOut.Reset()
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 3 {
+		t.Errorf("nested settings: Run() = %d, want 3 (stderr: %s)", exit, errOut.String())
+	}
+}
+
+// TestCheckCacheSkipsFailures verifies files that failed are not cached as
+// formatted, even though formatting left them unchanged
+func TestCheckCacheSkipsFailures(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	testCases := []struct {
+		name        string
+		content     string
+		checkNaming bool
+	}{
+		{"directive error", "# tffmt: indent\nresource \"example\" \"test\" {\n  foo = bar\n}\n\n", false},
+		{"failing lint issue", "resource \"example\" \"Test\" {\n  foo = bar\n}\n\n", true},
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origCache := cache
+	origPattern := namingPattern
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		cache = origCache
+		namingPattern = origPattern
+	}()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if _, err := gitOutput(".", "init", "-q"); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile("main.tf", []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := gitOutput(".", "add", "main.tf"); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg = config.NewConfig()
+			cfg.Check = true
+			cfg.CheckNaming = tc.checkNaming
+			formatterInst = formatter.New(cfg)
+			if err := setupLint(); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			if cache, err = loadCheckCache("cache.json"); err != nil {
+				t.Fatal(err)
+			}
+
+			res := processFile("main.tf")
+			if res.Changed || (res.Err == nil && len(res.Issues) == 0) {
+				t.Fatalf("processFile() = %+v, want an unchanged, failing result", res)
+			}
+			if cache.hit("main.tf") {
+				t.Errorf("cache.hit() = true after a failing result, want false")
+			}
+		})
+	}
+}
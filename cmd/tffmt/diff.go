@@ -0,0 +1,268 @@
+package tffmt
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/krewenki/tffmt/pkg/lint"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// sideBySideWidth caps the width of the left column of side-by-side diffs
+const sideBySideWidth = 60
+
+// validDiffFormat reports whether format is a supported -diff-format
+func validDiffFormat(format string) bool {
+	switch format {
+	case "unified", "context", "side-by-side", "git":
+		return true
+	}
+	return false
+}
+
+// diffText returns the changes between a and b in the given format
+func diffText(format, path string, a, b []byte) string {
+	switch format {
+	case "side-by-side":
+		return sideBySide(path, splitLines(a), splitLines(b))
+	case "git":
+		return gitDiff(path, a, b)
+	}
+
+	u := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: path + " (orig)",
+		ToFile:   path + " (fmt)",
+		Context:  3,
+	}
+	var text string
+	if format == "context" {
+		text, _ = difflib.GetContextDiffString(difflib.ContextDiff(u))
+	} else {
+		text, _ = difflib.GetUnifiedDiffString(u)
+	}
+	return text
+}
+
+// firstDiffLine returns the 1-based line of a where a and b first differ
+func firstDiffLine(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return bytes.Count(a[:i], []byte("\n")) + 1
+}
+
+// lineDelta counts the lines added and removed in turning a into b
+func lineDelta(a, b []byte) (added, removed int) {
+	from, to := splitLines(a), splitLines(b)
+	for _, op := range difflib.NewMatcher(from, to).GetOpCodes() {
+		if op.Tag != 'e' {
+			removed += op.I2 - op.I1
+			added += op.J2 - op.J1
+		}
+	}
+	return added, removed
+}
+
+// splitLines splits content into lines. Unlike difflib.SplitLines, it
+// doesn't add an empty line after a trailing newline.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// sideBySide renders the original and formatted lines in two columns, in
+// the style of diff -y: "|" marks changed lines, "<" removed lines and ">"
+// added lines
+func sideBySide(path string, from, to []string) string {
+	width := 0
+	for _, line := range from {
+		width = max(width, len(strings.TrimRight(line, "\n")))
+	}
+	width = min(width, sideBySideWidth)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-*s   %s\n", width, path+" (orig)", path+" (fmt)")
+	row := func(left, mark, right string) {
+		left = strings.TrimRight(left, "\n")
+		right = strings.TrimRight(right, "\n")
+		line := fmt.Sprintf("%-*s %s %s", width, left, mark, right)
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	matcher := difflib.NewMatcher(from, to)
+	for _, op := range matcher.GetOpCodes() {
+		switch op.Tag {
+		case 'e':
+			for i := op.I1; i < op.I2; i++ {
+				row(from[i], " ", to[op.J1+i-op.I1])
+			}
+		case 'r':
+			n := max(op.I2-op.I1, op.J2-op.J1)
+			for k := range n {
+				left, right := "", ""
+				mark := "|"
+				if op.I1+k < op.I2 {
+					left = from[op.I1+k]
+				} else {
+					mark = ">"
+				}
+				if op.J1+k < op.J2 {
+					right = to[op.J1+k]
+				} else {
+					mark = "<"
+				}
+				row(left, mark, right)
+			}
+		case 'd':
+			for i := op.I1; i < op.I2; i++ {
+				row(from[i], "<", "")
+			}
+		case 'i':
+			for j := op.J1; j < op.J2; j++ {
+				row("", ">", to[j])
+			}
+		}
+	}
+	return b.String()
+}
+
+// blockDiff returns a unified diff of a and b with each hunk put under a
+// header naming the top-level block of the original it changes, such as
+// "in resource.aws_instance.web:". Hunks outside of any block are put
+// under "in top level:".
+func blockDiff(path string, a, b []byte) string {
+	from, to := splitLines(a), splitLines(b)
+	blocks := blockLines(a)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s (orig)\n+++ %s (fmt)\n", path, path)
+	header := ""
+	matcher := difflib.NewMatcher(from, to)
+	for _, group := range matcher.GetGroupedOpCodes(3) {
+		if name := hunkBlock(group, blocks); name != header {
+			fmt.Fprintf(&out, "in %s:\n", name)
+			header = name
+		}
+
+		writeHunk(&out, group, from, to)
+	}
+	return out.String()
+}
+
+// gitDiff returns a unified diff of a and b with the headers of git diff,
+// so tools reading git's output, like delta, can show it
+func gitDiff(path string, a, b []byte) string {
+	name := strings.TrimLeft(strings.TrimPrefix(filepath.ToSlash(path), "./"), "/")
+	from, to := splitLines(a), splitLines(b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "diff --git a/%s b/%s\n", name, name)
+	fmt.Fprintf(&out, "index %s..%s 100644\n", blobHash(a)[:7], blobHash(b)[:7])
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	matcher := difflib.NewMatcher(from, to)
+	for _, group := range matcher.GetGroupedOpCodes(3) {
+		writeHunk(&out, group, from, to)
+	}
+	return out.String()
+}
+
+// blobHash returns the object id git gives content stored as a blob
+func blobHash(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// blockRange is the lines spanned by a top-level block
+type blockRange struct {
+	name       string
+	start, end int // 1-based and inclusive
+}
+
+// blockLines returns the line ranges of the top-level blocks of content,
+// or nothing if it doesn't parse
+func blockLines(content []byte) []blockRange {
+	body, err := lint.Parse(content, "")
+	if err != nil {
+		return nil
+	}
+	var blocks []blockRange
+	for _, block := range body.Blocks {
+		name := strings.Join(append([]string{block.Type}, block.Labels...), ".")
+		rng := block.Range()
+		blocks = append(blocks, blockRange{name, rng.Start.Line, rng.End.Line})
+	}
+	return blocks
+}
+
+// hunkBlock names the block holding the first change of a hunk
+func hunkBlock(group []difflib.OpCode, blocks []blockRange) string {
+	for _, op := range group {
+		if op.Tag == 'e' {
+			continue
+		}
+		// Lines inserted at the end of a block come right after its last
+		// line, so look at the line before them too
+		for _, line := range []int{op.I1 + 1, op.I1} {
+			for _, block := range blocks {
+				if line >= block.start && line <= block.end {
+					return block.name
+				}
+			}
+		}
+		break
+	}
+	return "top level"
+}
+
+// writeHunk writes the unified diff hunk of a group of changes between
+// from and to
+func writeHunk(out *strings.Builder, group []difflib.OpCode, from, to []string) {
+	first, last := group[0], group[len(group)-1]
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2))
+	for _, op := range group {
+		if op.Tag == 'e' {
+			writeLines(out, " ", from[op.I1:op.I2])
+			continue
+		}
+		if op.Tag == 'r' || op.Tag == 'd' {
+			writeLines(out, "-", from[op.I1:op.I2])
+		}
+		if op.Tag == 'r' || op.Tag == 'i' {
+			writeLines(out, "+", to[op.J1:op.J2])
+		}
+	}
+}
+
+// hunkRange formats the lines [start, end) for a unified diff hunk header
+func hunkRange(start, end int) string {
+	length := end - start
+	switch {
+	case length == 0:
+		return fmt.Sprintf("%d,0", start)
+	case length == 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// writeLines writes lines to out, each prefixed with prefix
+func writeLines(out *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		out.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package tffmt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestDiffText verifies each -diff-format produces its own style of diff
+func TestDiffText(t *testing.T) {
+	orig := []byte("resource \"a\" \"b\" {\nfoo = bar\n}\n")
+	formatted := []byte("resource \"a\" \"b\" {\n  foo = bar\n}\n\n")
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "unified",
+			expected: "--- main.tf (orig)\n" +
+				"+++ main.tf (fmt)\n" +
+				"@@ -1,4 +1,5 @@\n" +
+				" resource \"a\" \"b\" {\n" +
+				"-foo = bar\n" +
+				"+  foo = bar\n" +
+				" }\n" +
+				" \n" +
+				"+\n",
+		},
+		{
+			format: "context",
+			expected: "*** main.tf (orig)\n" +
+				"--- main.tf (fmt)\n" +
+				"***************\n" +
+				"*** 1,4 ****\n" +
+				"  resource \"a\" \"b\" {\n" +
+				"! foo = bar\n" +
+				"  }\n" +
+				"  \n" +
+				"--- 1,5 ----\n" +
+				"  resource \"a\" \"b\" {\n" +
+				"!   foo = bar\n" +
+				"  }\n" +
+				"  \n" +
+				"+ \n",
+		},
+		{
+			// As printed by git diff for the same change
+			format: "git",
+			expected: "diff --git a/main.tf b/main.tf\n" +
+				"index a7ff05a..aefa9b0 100644\n" +
+				"--- a/main.tf\n" +
+				"+++ b/main.tf\n" +
+				"@@ -1,3 +1,4 @@\n" +
+				" resource \"a\" \"b\" {\n" +
+				"-foo = bar\n" +
+				"+  foo = bar\n" +
+				" }\n" +
+				"+\n",
+		},
+		{
+			format: "side-by-side",
+			expected: `main.tf (orig)       main.tf (fmt)
+resource "a" "b" {   resource "a" "b" {
+foo = bar          |   foo = bar
+}                    }
+                   >
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if text := diffText(tt.format, "main.tf", orig, formatted); text != tt.expected {
+				t.Errorf("diffText(%q) =\n%s\nwant:\n%s", tt.format, text, tt.expected)
+			}
+		})
+	}
+}
+
+// TestGitDiffPaths verifies git diffs name files relative to a/ and b/
+func TestGitDiffPaths(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"main.tf", "diff --git a/main.tf b/main.tf\n"},
+		{"./modules/vpc/main.tf", "diff --git a/modules/vpc/main.tf b/modules/vpc/main.tf\n"},
+		{"/src/main.tf", "diff --git a/src/main.tf b/src/main.tf\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			text := diffText("git", tt.path, []byte("a = 1\n"), []byte("a = 2\n"))
+			header, _, _ := strings.Cut(text, "\n")
+			if header+"\n" != tt.expected {
+				t.Errorf("diffText() header = %q, want %q", header+"\n", tt.expected)
+			}
+			name := strings.TrimPrefix(strings.TrimSuffix(tt.expected, "\n"), "diff --git a/")
+			name, _, _ = strings.Cut(name, " ")
+			if !strings.Contains(text, "\n--- a/"+name+"\n+++ b/"+name+"\n") {
+				t.Errorf("diffText() = %q, want ---/+++ lines for a/%s and b/%s", text, name, name)
+			}
+		})
+	}
+}
+
+// TestFirstDiffLine verifies the line reported by -first-diff-line is the
+// first one formatting changes
+func TestFirstDiffLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		orig     string
+		expected int
+	}{
+		{"first line", "resource \"a\" \"b\" {foo = bar}", 1},
+		{"fourth line", "variable \"a\" {\n  type = string\n}\nvariable \"b\" {}\n", 4},
+		{"trailing newlines", "variable \"a\" {}\n", 2},
+	}
+
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout := stdout
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout = origStdout
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = config.NewConfig()
+			cfg.Check = true
+			cfg.FirstDiffLine = true
+			formatterInst = formatter.New(cfg)
+			var out strings.Builder
+			stdout = &out
+
+			exit := 0
+			_ = handleResult(formatContent("main.tf", []byte(tt.orig)), &exit)
+			if expected := fmt.Sprintf("main.tf:%d: needs formatting\n", tt.expected); out.String() != expected {
+				t.Errorf("output = %q, want %q", out.String(), expected)
+			}
+			if exit != 3 {
+				t.Errorf("exit = %d, want 3", exit)
+			}
+		})
+	}
+}
+
+// TestBlockDiff verifies hunks are labeled with the block they change
+func TestBlockDiff(t *testing.T) {
+	orig := []byte(`resource "aws_instance" "web" {
+ami = "ami-123"
+  instance_type = "t3.micro"
+  monitoring    = true
+  ebs_optimized = true
+  tags          = {}
+}
+
+variable "region" {
+  type    = string
+  default = "us-east-1"
+}
+
+locals {
+name = "app"
+}
+`)
+	formatted := []byte(`resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+  monitoring    = true
+  ebs_optimized = true
+  tags          = {}
+}
+
+variable "region" {
+  type    = string
+  default = "us-east-1"
+}
+
+locals {
+  name = "app"
+}
+`)
+
+	expected := "--- main.tf (orig)\n" +
+		"+++ main.tf (fmt)\n" +
+		"in resource.aws_instance.web:\n" +
+		"@@ -1,5 +1,5 @@\n" +
+		" resource \"aws_instance\" \"web\" {\n" +
+		"-ami = \"ami-123\"\n" +
+		"+  ami           = \"ami-123\"\n" +
+		"   instance_type = \"t3.micro\"\n" +
+		"   monitoring    = true\n" +
+		"   ebs_optimized = true\n" +
+		"in locals:\n" +
+		"@@ -12,5 +12,5 @@\n" +
+		" }\n" +
+		" \n" +
+		" locals {\n" +
+		"-name = \"app\"\n" +
+		"+  name = \"app\"\n" +
+		" }\n"
+	if got := blockDiff("main.tf", orig, formatted); got != expected {
+		t.Errorf("blockDiff() = %q, want %q", got, expected)
+	}
+}
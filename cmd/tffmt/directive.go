@@ -0,0 +1,19 @@
+package tffmt
+
+import (
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// directiveFormatter returns a formatter like f with the settings of the
+// "# tffmt:" directive on the first line of content, which win over every
+// other setting for that file, or f itself when content has none
+func directiveFormatter(f *formatter.Formatter, content []byte) (*formatter.Formatter, error) {
+	settings, ok, err := config.Directive(content)
+	if !ok || err != nil {
+		return f, err
+	}
+	c := f.Config.Clone()
+	config.ApplySettings(c, settings, nil)
+	return formatter.New(c), nil
+}
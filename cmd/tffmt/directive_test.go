@@ -0,0 +1,110 @@
+package tffmt
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestDirectiveIndent verifies a file with an indent directive is formatted
+// with its indentation while other files keep the default
+func TestDirectiveIndent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "no directive",
+			content:  "resource \"a\" \"b\" {\nfoo = 1\n}\n",
+			expected: "resource \"a\" \"b\" {\n  foo = 1\n}\n\n",
+		},
+		{
+			name:     "indent directive",
+			content:  "# tffmt: indent=4\nresource \"a\" \"b\" {\nfoo = 1\n}\n",
+			expected: "# tffmt: indent=4\nresource \"a\" \"b\" {\n    foo = 1\n}\n\n",
+		},
+		{
+			name:     "indent and blank lines directive",
+			content:  "# tffmt: indent=4 blank-lines=2\nresource \"a\" \"b\" {\nfoo = 1\n}\nresource \"a\" \"c\" {\nfoo = 2\n}\n",
+			expected: "# tffmt: indent=4 blank-lines=2\nresource \"a\" \"b\" {\n    foo = 1\n}\n\nresource \"a\" \"c\" {\n    foo = 2\n}\n\n",
+		},
+		{
+			name:     "single spacing directive",
+			content:  "# tffmt: blank_lines=1\nresource \"a\" \"b\" {\n  foo = 1\n}\n\nresource \"a\" \"c\" {\n  foo = 2\n}\n",
+			expected: "# tffmt: blank_lines=1\nresource \"a\" \"b\" {\n  foo = 1\n}\nresource \"a\" \"c\" {\n  foo = 2\n}\n\n",
+		},
+		{
+			name:     "invalid directive",
+			content:  "# tffmt: indent\nresource \"a\" \"b\" {\nfoo = 1\n}\n",
+			expected: "# tffmt: indent\nresource \"a\" \"b\" {\nfoo = 1\n}\n",
+			wantErr:  true,
+		},
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	formatterInst = formatter.New(cfg)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := formatContent("main.tf", []byte(tt.content))
+			if (res.Err != nil) != tt.wantErr {
+				t.Fatalf("formatContent() error = %v, want error: %v", res.Err, tt.wantErr)
+			}
+			if string(res.Formatted) != tt.expected {
+				t.Errorf("formatContent() = %q, want %q", res.Formatted, tt.expected)
+			}
+		})
+	}
+	if cfg.Indent != 2 {
+		t.Errorf("cfg.Indent = %d after a directive, want 2", cfg.Indent)
+	}
+}
+
+// TestDirectiveConcurrent verifies files with different directives can be
+// formatted at once without their settings leaking into each other or the
+// run's config. Run it with -race.
+func TestDirectiveConcurrent(t *testing.T) {
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	cfg.PreserveAttrs = []string{"tags"}
+	formatterInst = formatter.New(cfg)
+
+	var wg sync.WaitGroup
+	for width := 1; width <= 8; width++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			directive := fmt.Sprintf("# tffmt: indent=%d preserve-attrs=[tags,name]\n", width)
+			res := formatContent("main.tf", []byte(directive+"resource \"a\" \"b\" {\nfoo = 1\n}\n"))
+			want := directive + "resource \"a\" \"b\" {\n" + strings.Repeat(" ", width) + "foo = 1\n}\n\n"
+			if res.Err != nil || string(res.Formatted) != want {
+				t.Errorf("formatContent() = %q, %v, want %q", res.Formatted, res.Err, want)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cfg.Indent != 2 || len(cfg.PreserveAttrs) != 1 {
+		t.Errorf("cfg = indent %d, preserve-attrs %v after directives, want 2, [tags]", cfg.Indent, cfg.PreserveAttrs)
+	}
+}
@@ -0,0 +1,40 @@
+package tffmt
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var generatedMarker *regexp.Regexp
+
+// setupGenerated compiles the -generated-marker pattern for -skip-generated
+func setupGenerated() error {
+	if !cfg.SkipGenerated {
+		return nil
+	}
+	re, err := regexp.Compile(cfg.GeneratedMarker)
+	if err != nil {
+		return fmt.Errorf("invalid -generated-marker: %w", err)
+	}
+	generatedMarker = re
+	return nil
+}
+
+// isGenerated reports whether the first line of the file at path matches
+// the generated marker, reading no more of the file than that line
+func isGenerated(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && line == "" {
+		return false, nil
+	}
+	return generatedMarker.MatchString(strings.TrimRight(line, "\r\n")), nil
+}
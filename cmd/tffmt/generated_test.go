@@ -0,0 +1,65 @@
+package tffmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestSkipGenerated verifies files with the generated marker are skipped
+// while other files are still formatted
+func TestSkipGenerated(t *testing.T) {
+	tmpDir := t.TempDir()
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	generated := "# Code generated by terraform-gen. DO NOT EDIT.\n" + unformatted
+	generatedPath := filepath.Join(tmpDir, "generated.tf")
+	handwrittenPath := filepath.Join(tmpDir, "main.tf")
+	if err := os.WriteFile(generatedPath, []byte(generated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(handwrittenPath, []byte(unformatted), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origMarker := generatedMarker
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		generatedMarker = origMarker
+	}()
+
+	cfg = config.NewConfig()
+	cfg.List = false
+	cfg.SkipGenerated = true
+	formatterInst = formatter.New(cfg)
+	if err := setupGenerated(); err != nil {
+		t.Fatal(err)
+	}
+
+	exit := 0
+	if err := walkDir(tmpDir, &exit); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(generatedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != generated {
+		t.Errorf("generated file was formatted: %q", output)
+	}
+
+	output, err = os.ReadFile(handwrittenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) == unformatted {
+		t.Errorf("unmarked file was not formatted")
+	}
+}
@@ -0,0 +1,65 @@
+package tffmt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitOutput runs a git command in dir and returns its standard output
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(context.Background(), "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// gitBlobSHAs returns the blob SHA recorded in the git index for every
+// tracked file under dir, keyed by absolute path. Files with unstaged
+// modifications are left out because their index SHA no longer describes
+// the content on disk.
+func gitBlobSHAs(dir string) (map[string]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := gitOutput(absDir, "ls-files", "-s", "-z")
+	if err != nil {
+		return nil, err
+	}
+	modified, err := gitOutput(absDir, "ls-files", "-m", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	dirty := make(map[string]bool)
+	for _, name := range strings.Split(string(modified), "\x00") {
+		if name != "" {
+			dirty[name] = true
+		}
+	}
+
+	shas := make(map[string]string)
+	for _, entry := range strings.Split(string(staged), "\x00") {
+		// Each entry looks like "<mode> <sha> <stage>\t<path>"
+		meta, name, ok := strings.Cut(entry, "\t")
+		if !ok || dirty[name] {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 {
+			continue
+		}
+		shas[filepath.Join(absDir, filepath.FromSlash(name))] = fields[1]
+	}
+	return shas, nil
+}
@@ -0,0 +1,34 @@
+package tffmt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+)
+
+// errInterrupted stops processing after an interrupt
+var errInterrupted = errors.New("interrupted")
+
+// interrupted is set once the run is interrupted. Files already being
+// formatted are finished, including their write, but no new ones start.
+var interrupted atomic.Bool
+
+// catchInterrupts sets interrupted on SIGINT instead of killing the process
+// part way through writing a file, until the returned func is called
+func catchInterrupts() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		for range signals {
+			if !interrupted.Swap(true) {
+				fmt.Fprintln(stderr, "tffmt: interrupted, finishing the files in progress")
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(signals)
+	}
+}
@@ -0,0 +1,77 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// interruptingFormatter formats like the default formatter, then
+// interrupts the run as if Ctrl-C was pressed while it was busy
+type interruptingFormatter struct{}
+
+func (interruptingFormatter) FormatStats(content []byte) ([]byte, formatter.Stats) {
+	defer interrupted.Store(true)
+	return formatter.New(config.NewConfig()).FormatStats(content)
+}
+
+// TestInterrupt verifies an interrupt lets the file in progress be written
+// in full and leaves every later file untouched
+func TestInterrupt(t *testing.T) {
+	tmpDir := t.TempDir()
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	for _, name := range []string{"a.tf", "b.tf", "c.tf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(unformatted), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emptyConfig := filepath.Join(t.TempDir(), "empty.yml")
+	if err := os.WriteFile(emptyConfig, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origTF := formatters[".tf"]
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		formatters[".tf"] = origTF
+		stdout, stderr = origStdout, origStderr
+		interrupted.Store(false)
+	}()
+
+	registerFormatter(".tf", func(string) Formatter { return interruptingFormatter{} }, always)
+
+	var out, errOut bytes.Buffer
+	args := []string{"-config", emptyConfig, "-write", "-parallel", "1", tmpDir}
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 130 {
+		t.Fatalf("exit = %d, want 130 (stderr: %s)", exit, errOut.String())
+	}
+
+	formatted, _ := formatter.New(config.NewConfig()).FormatStats([]byte(unformatted))
+	want := map[string]string{"a.tf": string(formatted), "b.tf": unformatted, "c.tf": unformatted}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("directory holds %d files, want %d", len(entries), len(want))
+	}
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+}
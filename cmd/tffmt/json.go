@@ -0,0 +1,47 @@
+package tffmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// fileDiff is the -diff output of a file under -json
+type fileDiff struct {
+	Path string `json:"path"`
+	Diff string `json:"diff"`
+}
+
+// jsonOutput holds the files listed and diffed under -json, which are
+// printed as a single JSON document at the end of the run
+var jsonOutput struct {
+	paths []string
+	diffs []fileDiff
+}
+
+// recordDiff holds back the diff of a file for the -json output
+func recordDiff(res FileResult) {
+	var b strings.Builder
+	showDiff(&b, res.Path, res.Orig, res.Formatted)
+	jsonOutput.diffs = append(jsonOutput.diffs, fileDiff{Path: res.Path, Diff: b.String()})
+}
+
+// printJSONOutput prints the diffs held back under -json as an array of
+// {path, diff} objects, or otherwise the listed paths as an array
+func printJSONOutput() {
+	var v any
+	switch {
+	case cfg.Diff:
+		v = append([]fileDiff{}, jsonOutput.diffs...)
+	case cfg.List || cfg.ListUnchanged || cfg.VerifyNoop:
+		v = append([]string{}, jsonOutput.paths...)
+	default:
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return
+	}
+	fmt.Fprintln(stdout, string(data))
+}
@@ -0,0 +1,80 @@
+package tffmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestJSONOutput verifies -json prints the listed files, or the diffs,
+// as a single JSON array without changing the exit code
+func TestJSONOutput(t *testing.T) {
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.tf": "resource \"example\" \"a\" {\nfoo = bar\n}\n\n",
+		"b.tf": "resource \"example\" \"b\" {\n  foo = bar\n}\n\n",
+		"c.tf": "resource \"example\" \"c\" {\nfoo = bar\n}\n\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changed := []string{filepath.Join(tmpDir, "a.tf"), filepath.Join(tmpDir, "c.tf")}
+
+	t.Run("list", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		if exit := Run([]string{"-check", "-json", tmpDir}, nil, &out, &errOut); exit != 3 {
+			t.Fatalf("Run() = %d, want 3 (stderr: %s)", exit, errOut.String())
+		}
+		var paths []string
+		if err := json.Unmarshal(out.Bytes(), &paths); err != nil {
+			t.Fatalf("output isn't JSON: %v\n%s", err, out.String())
+		}
+		if strings.Join(paths, ",") != strings.Join(changed, ",") {
+			t.Errorf("-json listed %v, want %v", paths, changed)
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		if exit := Run([]string{"-write=false", "-diff", "-json", tmpDir}, nil, &out, &errOut); exit != 0 {
+			t.Fatalf("Run() = %d, want 0 (stderr: %s)", exit, errOut.String())
+		}
+		var diffs []fileDiff
+		if err := json.Unmarshal(out.Bytes(), &diffs); err != nil {
+			t.Fatalf("output isn't JSON: %v\n%s", err, out.String())
+		}
+		if len(diffs) != len(changed) {
+			t.Fatalf("-json printed %d diffs, want %d:\n%s", len(diffs), len(changed), out.String())
+		}
+		for i, d := range diffs {
+			if d.Path != changed[i] || !strings.Contains(d.Diff, "+  foo = bar") {
+				t.Errorf("diff %d = %+v, want the diff of %s", i, d, changed[i])
+			}
+		}
+	})
+
+	t.Run("nothing changed", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		path := filepath.Join(tmpDir, "b.tf")
+		if exit := Run([]string{"-check", "-json", path}, nil, &out, &errOut); exit != 0 {
+			t.Fatalf("Run() = %d, want 0 (stderr: %s)", exit, errOut.String())
+		}
+		if got := strings.TrimSpace(out.String()); got != "[]" {
+			t.Errorf("-json printed %q, want []", got)
+		}
+	})
+}
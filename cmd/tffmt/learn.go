@@ -0,0 +1,266 @@
+package tffmt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/krewenki/tffmt/pkg/lint"
+)
+
+// learnSample caps the number of files -learn looks at
+const learnSample = 200
+
+// conventions are the formatting habits -learn found in a repository
+type conventions struct {
+	files int
+
+	// blankLines and trailingNewlines are the most common number of blank
+	// lines between top-level blocks and of newlines ending a file
+	blankLines       int
+	trailingNewlines int
+
+	// aligned is set when most runs of attributes align their "="
+	aligned bool
+
+	commentStyle string
+	sortedVars   bool
+	sortedInputs bool
+}
+
+// learn infers the conventions of the terraform files under dir and writes
+// a .tffmt.yml there with the settings that change them the least
+func learn(dir string) int {
+	path := filepath.Join(dir, ".tffmt.yml")
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(stderr, "tffmt: %s already exists\n", path)
+		return 1
+	}
+
+	conv, err := learnConventions(dir)
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	if conv.files == 0 {
+		fmt.Fprintf(stderr, "tffmt: no terraform files to learn from in %s\n", dir)
+		return 1
+	}
+	if err := os.WriteFile(path, []byte(conv.settings(dir)), 0644); err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "wrote %s from %d files\n", path, conv.files)
+	return 0
+}
+
+// learnConventions tallies the conventions of up to learnSample terraform
+// files under dir. Files that don't parse are skipped.
+func learnConventions(dir string) (conventions, error) {
+	var paths []string
+	errSampled := errors.New("sampled enough files")
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isTerraformFile(path) {
+			paths = append(paths, path)
+		}
+		if len(paths) == learnSample {
+			return errSampled
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSampled) {
+		return conventions{}, err
+	}
+
+	conv := conventions{sortedVars: true, sortedInputs: true}
+	blankLines := map[int]int{}
+	trailing := map[int]int{}
+	aligned, unaligned := 0, 0
+	hash, slash := 0, 0
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return conventions{}, err
+		}
+		body, err := lint.Parse(content, path)
+		if err != nil {
+			continue
+		}
+		conv.files++
+		lines := strings.Split(string(content), "\n")
+
+		trailing[len(content)-len(strings.TrimRight(string(content), "\n"))]++
+		for i := 1; i < len(body.Blocks); i++ {
+			blankLines[blankLinesBetween(lines, body.Blocks[i-1], body.Blocks[i])]++
+		}
+
+		for _, group := range attributeRuns(body) {
+			if equalsAligned(lines, group) {
+				aligned++
+			} else {
+				unaligned++
+			}
+		}
+
+		tokens, _ := hclsyntax.LexConfig(content, path, body.SrcRange.Start)
+		for _, tok := range tokens {
+			if tok.Type != hclsyntax.TokenComment {
+				continue
+			}
+			if strings.HasPrefix(string(tok.Bytes), "//") {
+				slash++
+			} else if strings.HasPrefix(string(tok.Bytes), "#") {
+				hash++
+			}
+		}
+
+		conv.sortedVars = conv.sortedVars && variablesSorted(body)
+		conv.sortedInputs = conv.sortedInputs && inputsSorted(body)
+	}
+
+	conv.blankLines = mostCommon(blankLines, 1)
+	conv.trailingNewlines = mostCommon(trailing, 2)
+	conv.aligned = aligned >= unaligned
+	conv.commentStyle = "hash"
+	if slash > hash {
+		conv.commentStyle = "slash"
+	}
+	return conv, nil
+}
+
+// settings renders the conventions as a .tffmt.yml. Conventions tffmt
+// always enforces are listed as comments, to show how much will change.
+func (c conventions) settings(dir string) string {
+	yesNo := map[bool]string{true: "yes", false: "no"}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Learned by tffmt -learn from %d files in %s\n", c.files, dir)
+	fmt.Fprintf(&b, "comment-style: %s\n", c.commentStyle)
+	fmt.Fprintf(&b, "sort-vars: %v\n", c.sortedVars)
+	fmt.Fprintf(&b, "sort-inputs: %v\n", c.sortedInputs)
+	fmt.Fprintf(&b, "block-spacing: %d\n", min(c.blankLines, 1)+1)
+	b.WriteString("\n# Conventions found that tffmt doesn't make configurable:\n")
+	if c.blankLines > 1 {
+		fmt.Fprintf(&b, "#   blank lines between blocks: %d (tffmt uses at most 1)\n", c.blankLines)
+	}
+	fmt.Fprintf(&b, "#   trailing newlines: %d (tffmt uses 2)\n", c.trailingNewlines)
+	fmt.Fprintf(&b, "#   aligned attributes: %s (tffmt: yes)\n", yesNo[c.aligned])
+	return b.String()
+}
+
+// blankLinesBetween counts the empty lines between two top-level blocks
+func blankLinesBetween(lines []string, prev, next *hclsyntax.Block) int {
+	n := 0
+	for line := prev.Range().End.Line; line < next.Range().Start.Line-1; line++ {
+		if strings.TrimSpace(lines[line]) == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// attributeRuns returns the runs of at least two single-line attributes on
+// consecutive lines in the bodies of the top-level blocks
+func attributeRuns(body *hclsyntax.Body) [][]*hclsyntax.Attribute {
+	var runs [][]*hclsyntax.Attribute
+	for _, block := range body.Blocks {
+		var attrs []*hclsyntax.Attribute
+		for _, attr := range block.Body.Attributes {
+			if attr.SrcRange.Start.Line == attr.SrcRange.End.Line {
+				attrs = append(attrs, attr)
+			}
+		}
+		sort.Slice(attrs, func(i, j int) bool {
+			return attrs[i].SrcRange.Start.Line < attrs[j].SrcRange.Start.Line
+		})
+
+		var run []*hclsyntax.Attribute
+		for _, attr := range attrs {
+			if len(run) > 0 && attr.SrcRange.Start.Line != run[len(run)-1].SrcRange.Start.Line+1 {
+				if len(run) > 1 {
+					runs = append(runs, run)
+				}
+				run = nil
+			}
+			run = append(run, attr)
+		}
+		if len(run) > 1 {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// equalsAligned reports whether the "=" of every attribute in a run is in
+// the same column
+func equalsAligned(lines []string, run []*hclsyntax.Attribute) bool {
+	column := -1
+	for _, attr := range run {
+		c := strings.Index(lines[attr.SrcRange.Start.Line-1], "=")
+		if column >= 0 && c != column {
+			return false
+		}
+		column = c
+	}
+	return true
+}
+
+// variablesSorted reports whether the variable blocks of body are in order
+// of their names
+func variablesSorted(body *hclsyntax.Body) bool {
+	var names []string
+	for _, block := range body.Blocks {
+		if block.Type == "variable" && len(block.Labels) > 0 {
+			names = append(names, block.Labels[0])
+		}
+	}
+	return sort.StringsAreSorted(names)
+}
+
+// inputsSorted reports whether the attributes of every resource block of
+// body are in alphabetical order
+func inputsSorted(body *hclsyntax.Body) bool {
+	for _, block := range body.Blocks {
+		if block.Type != "resource" {
+			continue
+		}
+		attrs := make([]*hclsyntax.Attribute, 0, len(block.Body.Attributes))
+		for _, attr := range block.Body.Attributes {
+			attrs = append(attrs, attr)
+		}
+		sort.Slice(attrs, func(i, j int) bool {
+			return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+		})
+		for i := 1; i < len(attrs); i++ {
+			if attrs[i].Name < attrs[i-1].Name {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// mostCommon returns the key with the highest count, preferring the
+// smallest key on ties, or fallback when counts is empty
+func mostCommon(counts map[int]int, fallback int) int {
+	best, bestCount := fallback, 0
+	for key, count := range counts {
+		if count > bestCount || count == bestCount && key < best {
+			best, bestCount = key, count
+		}
+	}
+	return best
+}
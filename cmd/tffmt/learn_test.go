@@ -0,0 +1,108 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestLearn verifies -learn infers the conventions of a repository and
+// writes them to a .tffmt.yml
+func TestLearn(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"variables.tf": `# Inputs
+variable "environment" {
+  type    = string
+  default = "dev"
+}
+
+variable "region" {
+  type = string
+}
+`,
+		"modules/app/main.tf": `resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  ami           = var.ami
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+# Outputs
+output "id" {
+  value = aws_instance.web.id
+}
+`,
+		"broken.tf": "resource {",
+	}
+	for path, content := range files {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout := stdout
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout = origStdout
+	}()
+
+	cfg = config.NewConfig()
+	formatterInst = formatter.New(cfg)
+	stdout = &bytes.Buffer{}
+
+	conv, err := learnConventions(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := conventions{
+		files:            2,
+		blankLines:       1,
+		trailingNewlines: 1,
+		aligned:          true,
+		commentStyle:     "hash",
+		sortedVars:       true,
+		sortedInputs:     false,
+	}
+	if conv != want {
+		t.Errorf("learnConventions() = %+v, want %+v", conv, want)
+	}
+
+	if exit := learn(tmpDir); exit != 0 {
+		t.Fatalf("learn() = %d, want 0", exit)
+	}
+	settings, err := config.LoadSettingsFile(filepath.Join(tmpDir, ".tffmt.yml"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.SortVars == nil || !*settings.SortVars {
+		t.Errorf("learned sort-vars = %v, want true", settings.SortVars)
+	}
+	if settings.SortInputs == nil || *settings.SortInputs {
+		t.Errorf("learned sort-inputs = %v, want false", settings.SortInputs)
+	}
+	if settings.BlockSpacing == nil || *settings.BlockSpacing != 2 {
+		t.Errorf("learned block-spacing = %v, want 2", settings.BlockSpacing)
+	}
+	if settings.CommentStyle == nil || *settings.CommentStyle != "hash" {
+		t.Errorf("learned comment-style = %v, want hash", settings.CommentStyle)
+	}
+
+	if exit := learn(tmpDir); exit != 1 {
+		t.Errorf("learn() over an existing .tffmt.yml = %d, want 1", exit)
+	}
+}
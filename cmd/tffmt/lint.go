@@ -0,0 +1,104 @@
+package tffmt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/krewenki/tffmt/pkg/lint"
+)
+
+var namingPattern *regexp.Regexp
+
+// providerAliases caches the aliased providers declared in each directory
+// for -check-provider-refs
+var providerAliases = struct {
+	sync.Mutex
+	dirs map[string]map[string]bool
+}{dirs: map[string]map[string]bool{}}
+
+// setupLint prepares the enabled lint checks from the configuration
+func setupLint() error {
+	if cfg.CheckNaming {
+		re, err := regexp.Compile(cfg.NamingPattern)
+		if err != nil {
+			return fmt.Errorf("invalid -naming-pattern: %w", err)
+		}
+		namingPattern = re
+	}
+	return nil
+}
+
+// lintEnabled reports whether any lint check should run
+func lintEnabled() bool {
+	return cfg.CheckNaming || cfg.CheckBackend || cfg.CheckProviderRefs || cfg.CheckEmptyDefaults || cfg.CheckAlignment
+}
+
+// lintFile runs the enabled lint checks over the content of a file
+func lintFile(path string, content []byte) ([]lint.Issue, error) {
+	body, err := lint.Parse(content, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []lint.Issue
+	if cfg.CheckNaming {
+		issues = append(issues, lint.CheckNaming(body, namingPattern)...)
+	}
+	if cfg.CheckBackend {
+		issues = append(issues, lint.CheckBackend(body)...)
+	}
+	if cfg.CheckEmptyDefaults {
+		issues = append(issues, lint.CheckEmptyDefaults(body)...)
+	}
+	if cfg.CheckAlignment {
+		issues = append(issues, lint.CheckAlignment(content, path)...)
+	}
+	if cfg.CheckProviderRefs {
+		declared, err := declaredProviders(filepath.Dir(path))
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, lint.CheckProviderRefs(body, declared)...)
+	}
+	return issues, nil
+}
+
+// declaredProviders returns the aliased providers declared by the terraform
+// files in dir, since they are usually declared in a different file of the
+// module than the resources using them
+func declaredProviders(dir string) (map[string]bool, error) {
+	providerAliases.Lock()
+	defer providerAliases.Unlock()
+	if declared, ok := providerAliases.dirs[dir]; ok {
+		return declared, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	declared := map[string]bool{}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !isTerraformFile(path) {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		body, err := lint.Parse(content, path)
+		if err != nil {
+			// The file reports its own parse error when it is processed
+			continue
+		}
+		for _, alias := range lint.ProviderAliases(body) {
+			declared[alias] = true
+		}
+	}
+	providerAliases.dirs[dir] = declared
+	return declared, nil
+}
@@ -0,0 +1,136 @@
+package tffmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestCheckNamingFlag verifies naming violations are reported and fail the run
+func TestCheckNamingFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectIssue bool
+		expectExit  int
+	}{
+		{"compliant name", "resource \"aws_instance\" \"web_server\" {}\n\n", false, 0},
+		{"camel case name", "resource \"aws_instance\" \"webServer\" {}\n\n", true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.CheckNaming = true
+			formatterInst = formatter.New(cfg)
+			if err := setupLint(); err != nil {
+				t.Fatal(err)
+			}
+
+			res := formatContent("main.tf", []byte(tt.content))
+			if (len(res.Issues) > 0) != tt.expectIssue {
+				t.Errorf("formatContent() issues = %v, want issue: %v", res.Issues, tt.expectIssue)
+			}
+
+			exit := 0
+			_ = handleResult(res, &exit)
+			if exit != tt.expectExit {
+				t.Errorf("handleResult() exit = %d, want %d", exit, tt.expectExit)
+			}
+		})
+	}
+}
+
+// TestCheckEmptyDefaultsFlag verifies empty string defaults are warned
+// about without failing the run
+func TestCheckEmptyDefaultsFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectIssue bool
+	}{
+		{"non-empty default", "variable \"prefix\" {\n  default = \"app\"\n}\n\n", false},
+		{"empty default", "variable \"prefix\" {\n  default = \"\"\n}\n\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.Check = true
+			cfg.CheckEmptyDefaults = true
+			formatterInst = formatter.New(cfg)
+
+			res := formatContent("main.tf", []byte(tt.content))
+			if (len(res.Issues) > 0) != tt.expectIssue {
+				t.Errorf("formatContent() issues = %v, want issue: %v", res.Issues, tt.expectIssue)
+			}
+
+			exit := 0
+			_ = handleResult(res, &exit)
+			if exit != 0 {
+				t.Errorf("handleResult() exit = %d, want 0", exit)
+			}
+		})
+	}
+}
+
+// TestSetupLintInvalidPattern verifies an invalid naming pattern is rejected
+func TestSetupLintInvalidPattern(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+
+	cfg = config.NewConfig()
+	cfg.CheckNaming = true
+	cfg.NamingPattern = "["
+	if err := setupLint(); err == nil {
+		t.Errorf("setupLint() with invalid pattern should return an error")
+	}
+}
+
+// TestCheckProviderRefsAcrossFiles verifies provider aliases declared in
+// another file of the same directory count as declared
+func TestCheckProviderRefsAcrossFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	providers := "provider \"aws\" {\n  alias = \"west\"\n}\n\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "providers.tf"), []byte(providers), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mainPath := filepath.Join(tmpDir, "main.tf")
+	content := "resource \"aws_instance\" \"a\" {\n  provider = aws.west\n}\n\nresource \"aws_instance\" \"b\" {\n  provider = aws.east\n}\n\n"
+
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	cfg.CheckProviderRefs = true
+	formatterInst = formatter.New(cfg)
+
+	res := formatContent(mainPath, []byte(content))
+	want := mainPath + ":6: resource aws_instance.b refers to undeclared provider aws.east"
+	if len(res.Issues) != 1 || res.Issues[0].String() != want {
+		t.Errorf("formatContent() issues = %v, want [%s]", res.Issues, want)
+	}
+}
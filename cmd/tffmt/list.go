@@ -0,0 +1,67 @@
+package tffmt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var (
+	// listGroups holds the listed files by directory under -group-list
+	listGroups = map[string][]string{}
+
+	// colorOutput is set when -group-list headers should be colored
+	colorOutput bool
+)
+
+// listPath lists path on stdout, or holds it back to be printed under its
+// directory's header at the end of the run under -group-list, or in the
+// -json output
+func listPath(path string) {
+	if cfg.JSON {
+		jsonOutput.paths = append(jsonOutput.paths, path)
+		return
+	}
+	if !cfg.GroupList {
+		fmt.Fprintln(stdout, path)
+		return
+	}
+	dir := filepath.Dir(path)
+	listGroups[dir] = append(listGroups[dir], path)
+}
+
+// printListGroups prints the files held back by -group-list, grouped under
+// a header for each directory
+func printListGroups() {
+	dirs := make([]string, 0, len(listGroups))
+	for dir := range listGroups {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for i, dir := range dirs {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		header := dir + "/"
+		if colorOutput {
+			header = "\x1b[1;34m" + header + "\x1b[0m"
+		}
+		fmt.Fprintln(stdout, header)
+		for _, path := range listGroups[dir] {
+			fmt.Fprintln(stdout, "  "+filepath.Base(path))
+		}
+	}
+}
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,56 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGroupList verifies -group-list prints listed files under a plain
+// header for their directory when stdout is not a terminal
+func TestGroupList(t *testing.T) {
+	tmpDir := t.TempDir()
+	unformatted := []byte("resource \"example\" \"test\" {foo = bar}")
+	for _, path := range []string{"a.tf", "net/vpc.tf", "net/subnet.tf", "z.tf"} {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, unformatted, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emptyConfig := filepath.Join(t.TempDir(), "empty.yml")
+	if err := os.WriteFile(emptyConfig, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	var out, errOut bytes.Buffer
+	args := []string{"-config", emptyConfig, "-check", "-recursive", "-group-list", tmpDir}
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 3 {
+		t.Fatalf("exit = %d, want 3 (stderr: %s)", exit, errOut.String())
+	}
+
+	want := tmpDir + "/\n" +
+		"  a.tf\n" +
+		"  z.tf\n" +
+		"\n" +
+		filepath.Join(tmpDir, "net") + "/\n" +
+		"  subnet.tf\n" +
+		"  vpc.tf\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,153 @@
+package tffmt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// lockWanted holds the hashes read from the -verify-lock file
+	lockWanted map[string]string
+
+	// lockSeen collects the hashes of the formatted files for -write-lock
+	lockSeen = map[string]string{}
+)
+
+// formattedHash returns the sha256 of the formatted content of a result.
+// Cached results are already formatted, so the file itself is hashed.
+func formattedHash(res FileResult) (string, error) {
+	content := res.Formatted
+	if res.Cached {
+		var err error
+		if content, err = os.ReadFile(res.Path); err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lockKey returns the key of path in lockFile: the path relative to the
+// directory of the lock file, so the lock doesn't depend on where tffmt runs
+func lockKey(path, lockFile string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	dir, err := filepath.Abs(filepath.Dir(lockFile))
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readLock parses a lock file of "<sha256>  <path>" lines
+func readLock(lockFile string) (map[string]string, error) {
+	data, err := os.ReadFile(lockFile)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, file, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected \"<sha256>  <path>\"", lockFile, n)
+		}
+		hashes[filepath.ToSlash(filepath.Clean(filepath.FromSlash(file)))] = hash
+	}
+	return hashes, scanner.Err()
+}
+
+// writeLock writes the hashes collected during the run to path
+func writeLock(path string) error {
+	files := make([]string, 0, len(lockSeen))
+	for file := range lockSeen {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var b strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&b, "%s  %s\n", lockSeen[file], file)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// checkLock records the hash of a result for -write-lock and compares it
+// with the -verify-lock file, returning an error on a mismatch or a
+// missing entry. Skipped files have no formatted content, so they aren't
+// locked.
+func checkLock(res FileResult) error {
+	if res.Skipped || (cfg.WriteLock == "" && lockWanted == nil) {
+		return nil
+	}
+	hash, err := formattedHash(res)
+	if err != nil {
+		return err
+	}
+
+	if cfg.WriteLock != "" {
+		lockSeen[lockKey(res.Path, cfg.WriteLock)] = hash
+	}
+	if lockWanted == nil {
+		return nil
+	}
+	want, ok := lockWanted[lockKey(res.Path, cfg.VerifyLock)]
+	if !ok {
+		return fmt.Errorf("%s: not in lock file %s", res.Path, cfg.VerifyLock)
+	}
+	if want != hash {
+		return fmt.Errorf("%s: formatted content doesn't match lock file %s", res.Path, cfg.VerifyLock)
+	}
+	return nil
+}
+
+// staleLockEntries returns the entries of the -verify-lock file whose files
+// no longer exist
+func staleLockEntries(lockFile string) []string {
+	var stale []string
+	for key := range lockWanted {
+		path := filepath.Join(filepath.Dir(lockFile), filepath.FromSlash(key))
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// finishLock reports the stale entries of the -verify-lock file and writes
+// the -write-lock file, failing the run when either goes wrong
+func finishLock(exit *int) {
+	if lockWanted != nil {
+		for _, key := range staleLockEntries(cfg.VerifyLock) {
+			fmt.Fprintf(stderr, "tffmt: %s: in lock file %s but doesn't exist\n", key, cfg.VerifyLock)
+			*exit = 1
+		}
+	}
+	if cfg.WriteLock != "" {
+		if err := writeLock(cfg.WriteLock); err != nil {
+			fmt.Fprintln(stderr, "tffmt: writing lock file:", err)
+			*exit = 1
+		}
+	}
+}
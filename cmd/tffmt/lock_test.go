@@ -0,0 +1,222 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestLockFile verifies a written lock file verifies against the same
+// files, and that a stale entry fails verification
+func TestLockFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	mainPath := filepath.Join(tmpDir, "main.tf")
+	varsPath := filepath.Join(tmpDir, "vars.tf")
+	if err := os.WriteFile(mainPath, []byte("resource \"example\" \"test\" {foo = bar}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(varsPath, []byte("variable \"name\" {}\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(tmpDir, ".tffmt.lock")
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origWanted, origSeen := lockWanted, lockSeen
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		lockWanted, lockSeen = origWanted, origSeen
+	}()
+
+	run := func() int {
+		t.Helper()
+		formatterInst = formatter.New(cfg)
+		exit := 0
+		if err := walkDir(tmpDir, &exit); err != nil {
+			t.Fatal(err)
+		}
+		return exit
+	}
+
+	// Generate the lock
+	cfg = config.NewConfig()
+	cfg.Write = false
+	cfg.List = false
+	cfg.WriteLock = lockPath
+	lockWanted, lockSeen = nil, map[string]string{}
+	if exit := run(); exit != 0 {
+		t.Fatalf("-write-lock exit = %d, want 0", exit)
+	}
+	if err := writeLock(lockPath); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(lock string) string
+		wantExit int
+	}{
+		{"matching lock", func(lock string) string { return lock }, 0},
+		{"mismatching entry", func(lock string) string {
+			return strings.Replace(lock, lock[:64], strings.Repeat("0", 64), 1)
+		}, 1},
+		{"missing entry", func(lock string) string {
+			return lock[strings.Index(lock, "\n")+1:]
+		}, 1},
+	}
+
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(lockPath, []byte(tt.edit(string(data))), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg = config.NewConfig()
+			cfg.Write = false
+			cfg.List = false
+			cfg.VerifyLock = lockPath
+			lockWanted, err = readLock(lockPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exit := run(); exit != tt.wantExit {
+				t.Errorf("-verify-lock exit = %d, want %d", exit, tt.wantExit)
+			}
+		})
+	}
+}
+
+// TestLockKey verifies lock keys are relative to the lock file, whichever
+// directory tffmt runs from
+func TestLockKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	lockPath := filepath.Join(tmpDir, ".tffmt.lock")
+	mainPath := filepath.Join(tmpDir, "modules", "vpc", "main.tf")
+
+	if got := lockKey(mainPath, lockPath); got != "modules/vpc/main.tf" {
+		t.Errorf("lockKey() = %q, want modules/vpc/main.tf", got)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "modules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(filepath.Join(tmpDir, "modules"))
+	if got := lockKey(filepath.Join("vpc", "main.tf"), filepath.Join("..", ".tffmt.lock")); got != "modules/vpc/main.tf" {
+		t.Errorf("lockKey() from modules = %q, want modules/vpc/main.tf", got)
+	}
+}
+
+// TestFinishLock verifies entries of deleted files and failing to write the
+// lock file fail the run
+func TestFinishLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.tf"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(tmpDir, ".tffmt.lock")
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origStderr := stderr
+	origWanted, origSeen := lockWanted, lockSeen
+	defer func() {
+		cfg = origCfg
+		stderr = origStderr
+		lockWanted, lockSeen = origWanted, origSeen
+	}()
+
+	tests := []struct {
+		name       string
+		verify     map[string]string
+		writeLock  string
+		wantStderr string
+	}{
+		{
+			name:       "deleted file",
+			verify:     map[string]string{"main.tf": "0", "gone.tf": "0"},
+			wantStderr: "tffmt: gone.tf: in lock file " + lockPath + " but doesn't exist\n",
+		},
+		{
+			name:       "unwritable lock file",
+			writeLock:  filepath.Join(tmpDir, "missing", ".tffmt.lock"),
+			wantStderr: "tffmt: writing lock file:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errOut bytes.Buffer
+			stderr = &errOut
+			cfg = config.NewConfig()
+			cfg.VerifyLock = lockPath
+			cfg.WriteLock = tt.writeLock
+			lockWanted, lockSeen = tt.verify, map[string]string{}
+
+			exit := 0
+			finishLock(&exit)
+			if exit != 1 {
+				t.Errorf("finishLock() exit = %d, want 1", exit)
+			}
+			if !strings.HasPrefix(errOut.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to start with %q", errOut.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+// TestLockSkippedFiles verifies files skipped without being formatted are
+// left out of the lock, so verifying it doesn't compare them
+func TestLockSkippedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	small := "variable \"name\" {}\n\n"
+	large := "resource \"example\" \"test\" {\n  foo = bar\n}\n\n" + strings.Repeat("# padding\n", 10)
+	if err := os.WriteFile(filepath.Join(tmpDir, "small.tf"), []byte(small), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "large.tf"), []byte(large), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(tmpDir, ".tffmt.lock")
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	origWanted, origSeen := lockWanted, lockSeen
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+		lockWanted, lockSeen = origWanted, origSeen
+	}()
+
+	var out, errOut bytes.Buffer
+	args := []string{"-check", "-max-file-size", "50", "-write-lock", lockPath, tmpDir}
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 0 {
+		t.Fatalf("-write-lock: Run() = %d, want 0 (stderr: %s)", exit, errOut.String())
+	}
+	lock, err := os.ReadFile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(lock), "large.tf") {
+		t.Errorf("lock file = %q, want no entry for the skipped large.tf", lock)
+	}
+
+	out.Reset()
+	errOut.Reset()
+	args = []string{"-check", "-max-file-size", "50", "-verify-lock", lockPath, tmpDir}
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 0 {
+		t.Errorf("-verify-lock: Run() = %d, want 0 (stderr: %s)", exit, errOut.String())
+	}
+}
@@ -0,0 +1,261 @@
+package tffmt
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// JSON-RPC error codes used by the language server
+const (
+	lspMethodNotFound = -32601
+	lspInvalidParams  = -32602
+	lspInternalError  = -32603
+)
+
+// lspMessage is a JSON-RPC request, notification or response
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  any              `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+// lspError is the error of a failed request
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lspPosition is a zero-based line and UTF-16 column in a document
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange is the span of a document between two positions
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// lspTextEdit replaces a range of a document with new text
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+// lspParams holds the fields of the requests and notifications served
+type lspParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+	Range *lspRange `json:"range"`
+}
+
+// lspServer is a language server that only formats documents
+type lspServer struct {
+	out *bufio.Writer
+
+	// docs holds the text of the open documents, by URI
+	docs     map[string]string
+	shutdown bool
+}
+
+// serveLSP runs a language server offering document and range formatting
+// over r and w until the client exits, returning the exit code
+func serveLSP(r io.Reader, w io.Writer) int {
+	s := &lspServer{out: bufio.NewWriter(w), docs: map[string]string{}}
+	in := bufio.NewReader(r)
+	for {
+		msg, err := readLSPMessage(in)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 1
+			}
+			fmt.Fprintln(stderr, "tffmt: lsp:", err)
+			return 1
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+		if err := s.handle(msg); err != nil {
+			fmt.Fprintln(stderr, "tffmt: lsp:", err)
+			return 1
+		}
+	}
+}
+
+// readLSPMessage reads a message framed by a Content-Length header
+func readLSPMessage(r *bufio.Reader) (lspMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return lspMessage{}, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return lspMessage{}, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return lspMessage{}, err
+	}
+	var msg lspMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return lspMessage{}, err
+	}
+	return msg, nil
+}
+
+// write sends a message framed by a Content-Length header
+func (s *lspServer) write(msg lspMessage) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body))
+	s.out.Write(body)
+	return s.out.Flush()
+}
+
+// handle serves a single message. Requests are answered, failing ones with
+// an error response, and unknown notifications are ignored.
+func (s *lspServer) handle(msg lspMessage) error {
+	var params lspParams
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.reply(msg, nil, &lspError{lspInvalidParams, err.Error()})
+		}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return s.reply(msg, map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":                1, // full
+				"documentFormattingProvider":      true,
+				"documentRangeFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "tffmt"},
+		}, nil)
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(msg, nil, nil)
+	case "textDocument/didOpen":
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+	case "textDocument/didChange":
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+	case "textDocument/didClose":
+		delete(s.docs, params.TextDocument.URI)
+	case "textDocument/formatting", "textDocument/rangeFormatting":
+		edits, err := s.format(params.TextDocument.URI, params.Range)
+		if err != nil {
+			return s.reply(msg, nil, &lspError{lspInternalError, err.Error()})
+		}
+		return s.reply(msg, edits, nil)
+	default:
+		if msg.ID != nil {
+			return s.reply(msg, nil, &lspError{lspMethodNotFound, "unsupported method " + msg.Method})
+		}
+	}
+	return nil
+}
+
+// reply answers the request msg, doing nothing for notifications
+func (s *lspServer) reply(msg lspMessage, result any, rpcErr *lspError) error {
+	if msg.ID == nil {
+		return nil
+	}
+	resp := lspMessage{ID: msg.ID, Result: result, Error: rpcErr}
+	if result == nil && rpcErr == nil {
+		resp.Result = json.RawMessage("null")
+	}
+	return s.write(resp)
+}
+
+// format returns the edits formatting the document at uri, or only the
+// lines of rng when it is non-nil. Documents that aren't open are read
+// from disk.
+func (s *lspServer) format(uri string, rng *lspRange) ([]lspTextEdit, error) {
+	path := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		path = u.Path
+	}
+	text, ok := s.docs[uri]
+	if !ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = string(content)
+	}
+
+	res := formatContent(path, []byte(text))
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	edits := textEdits(text, string(res.Formatted))
+	if rng == nil {
+		return edits, nil
+	}
+
+	// Keep the edits touching the lines of the range
+	inRange := []lspTextEdit{}
+	for _, edit := range edits {
+		if edit.Range.Start.Line <= rng.End.Line && edit.Range.End.Line >= rng.Start.Line {
+			inRange = append(inRange, edit)
+		}
+	}
+	return inRange, nil
+}
+
+// textEdits returns the edits turning text into formatted, one for each
+// run of changed lines
+func textEdits(text, formatted string) []lspTextEdit {
+	from, to := splitLines([]byte(text)), splitLines([]byte(formatted))
+	edits := []lspTextEdit{}
+	for _, op := range difflib.NewMatcher(from, to).GetOpCodes() {
+		if op.Tag == 'e' {
+			continue
+		}
+		edits = append(edits, lspTextEdit{
+			Range: lspRange{
+				Start: linePosition(from, op.I1),
+				End:   linePosition(from, op.I2),
+			},
+			NewText: strings.Join(to[op.J1:op.J2], ""),
+		})
+	}
+	return edits
+}
+
+// linePosition returns the position of the start of line i of lines, or
+// of the end of the text past the last line
+func linePosition(lines []string, i int) lspPosition {
+	if i == len(lines) && i > 0 && !strings.HasSuffix(lines[i-1], "\n") {
+		return lspPosition{i - 1, len(utf16.Encode([]rune(lines[i-1])))}
+	}
+	return lspPosition{Line: i}
+}
@@ -0,0 +1,122 @@
+package tffmt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// lspFrame frames a JSON-RPC message with its Content-Length header
+func lspFrame(t *testing.T, msg map[string]any) string {
+	t.Helper()
+	msg["jsonrpc"] = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// applyEdits applies edits starting and ending at line starts, or at the
+// end of text, in reverse order so earlier ranges stay valid
+func applyEdits(text string, edits []lspTextEdit) string {
+	lines := splitLines([]byte(text))
+	offset := func(pos lspPosition) int {
+		n := 0
+		for _, line := range lines[:pos.Line] {
+			n += len(line)
+		}
+		return n + pos.Character
+	}
+	for i := len(edits) - 1; i >= 0; i-- {
+		start, end := offset(edits[i].Range.Start), offset(edits[i].Range.End)
+		text = text[:start] + edits[i].NewText + text[end:]
+	}
+	return text
+}
+
+// TestServeLSP verifies formatting requests over the JSON-RPC framing
+// return edits that format the document
+func TestServeLSP(t *testing.T) {
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	formatterInst = formatter.New(cfg)
+
+	uri := "file:///work/main.tf"
+	text := "resource \"a\" \"b\" {\nfoo=1\n}\nresource \"a\" \"c\" {\n  bar = 2\n}"
+	selection := map[string]any{"start": map[string]int{"line": 0}, "end": map[string]int{"line": 2}}
+	doc := map[string]any{"uri": uri}
+	input := lspFrame(t, map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}}) +
+		lspFrame(t, map[string]any{"method": "initialized", "params": map[string]any{}}) +
+		lspFrame(t, map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "terraform", "version": 1, "text": text},
+		}}) +
+		lspFrame(t, map[string]any{"id": 2, "method": "textDocument/formatting", "params": map[string]any{"textDocument": doc}}) +
+		lspFrame(t, map[string]any{"id": 3, "method": "textDocument/rangeFormatting", "params": map[string]any{"textDocument": doc, "range": selection}}) +
+		lspFrame(t, map[string]any{"id": 4, "method": "textDocument/hover", "params": map[string]any{"textDocument": doc}}) +
+		lspFrame(t, map[string]any{"id": 5, "method": "shutdown"}) +
+		lspFrame(t, map[string]any{"method": "exit"})
+
+	var out bytes.Buffer
+	if exit := serveLSP(strings.NewReader(input), &out); exit != 0 {
+		t.Fatalf("exit = %d, want 0", exit)
+	}
+
+	responses := map[int]json.RawMessage{}
+	rpcErrors := map[int]*lspError{}
+	r := bufio.NewReader(&out)
+	for r.Buffered() > 0 || out.Len() > 0 {
+		msg, err := readLSPMessage(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var id int
+		if err := json.Unmarshal(*msg.ID, &id); err != nil {
+			t.Fatal(err)
+		}
+		result, _ := json.Marshal(msg.Result)
+		responses[id], rpcErrors[id] = result, msg.Error
+	}
+
+	var edits []lspTextEdit
+	if err := json.Unmarshal(responses[2], &edits); err != nil {
+		t.Fatalf("formatting result %s: %v", responses[2], err)
+	}
+	want := formatterInst.Format([]byte(text))
+	if got := applyEdits(text, edits); got != string(want) {
+		t.Errorf("document after formatting edits = %q, want %q", got, want)
+	}
+
+	var rangeEdits []lspTextEdit
+	if err := json.Unmarshal(responses[3], &rangeEdits); err != nil {
+		t.Fatalf("rangeFormatting result %s: %v", responses[3], err)
+	}
+	for _, edit := range rangeEdits {
+		if edit.Range.Start.Line > 2 {
+			t.Errorf("range formatting edited line %d, outside the range", edit.Range.Start.Line)
+		}
+	}
+	if len(rangeEdits) == 0 || len(rangeEdits) >= len(edits) {
+		t.Errorf("range formatting returned %d of %d edits, want only those in range", len(rangeEdits), len(edits))
+	}
+
+	if rpcErrors[4] == nil || rpcErrors[4].Code != lspMethodNotFound {
+		t.Errorf("hover error = %v, want method not found", rpcErrors[4])
+	}
+	if string(responses[5]) != "null" {
+		t.Errorf("shutdown result = %s, want null", responses[5])
+	}
+}
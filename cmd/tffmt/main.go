@@ -2,81 +2,474 @@
 package tffmt
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/krewenki/tffmt/pkg/config"
 	"github.com/krewenki/tffmt/pkg/formatter"
-	"github.com/pmezard/go-difflib/difflib"
+	"github.com/krewenki/tffmt/pkg/lint"
 )
 
 var (
 	cfg           *config.Config
 	formatterInst *formatter.Formatter
+	cache         *checkCache
+
+	// settingsCache finds and loads the settings files of directories
+	settingsCache *config.SettingsCache
+
+	// stdout and stderr are where Run writes its output
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+
+	// stdoutHeaders is set when -stdout prints more than a single file,
+	// which are told apart by a header naming each
+	stdoutHeaders bool
 )
 
+// errFailFast stops processing at the first failing file under -fail-fast
+var errFailFast = errors.New("stopped at first failure")
+
+// FileResult describes the outcome of formatting a single file
+type FileResult struct {
+	Path      string
+	Changed   bool
+	Err       error
+	Orig      []byte
+	Formatted []byte
+
+	// Stats records which formatting rules changed the file
+	Stats formatter.Stats
+
+	// Issues holds the problems reported by the enabled lint checks
+	Issues []lint.Issue
+
+	// Cached is set when the file was skipped because the check cache
+	// already knows it is formatted
+	Cached bool
+
+	// Note explains the change to the file in -verbose mode
+	Note string
+
+	// Skipped is set when the file was left alone because it is a symlink
+	// and -no-follow-symlink-write is set, generated and -skip-generated is
+	// set, or over -max-file-size
+	Skipped bool
+
+	// Warnings lists the rules skipped because they couldn't safely format
+	// the file
+	Warnings []formatter.Warning
+
+	// Reasons explains the changes made to the file under -explain
+	Reasons []string
+
+	// Interrupted is set when the file was never started because the run
+	// was interrupted first
+	Interrupted bool
+
+	// NoOp is set under -verify-noop when formatting leaves the file as it
+	// is, and formatting again does too
+	NoOp bool
+
+	// Unstable is set under -verify-noop when formatting the formatted
+	// content again changes it
+	Unstable bool
+}
+
 // Main is the entry point for the tffmt CLI
 func Main() {
+	os.Exit(Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// Run runs tffmt with the given command-line arguments and standard
+// streams, returning the exit code
+func Run(args []string, stdinR io.Reader, stdoutW, stderrW io.Writer) int {
+	stdout, stderr = &syncWriter{w: stdoutW}, &syncWriter{w: stderrW}
+	resetRun()
+
 	// Initialize configuration and formatter
 	cfg = config.NewConfig()
 	formatterInst = formatter.New(cfg)
 
 	// Setup command-line flags
-	flag.BoolVar(&cfg.Write, "write", cfg.Write, "write result to source file(s)")
-	flag.BoolVar(&cfg.Check, "check", cfg.Check, "check if files are already formatted")
-	flag.BoolVar(&cfg.List, "list", cfg.List, "list files whose formatting differs")
-	flag.BoolVar(&cfg.Diff, "diff", cfg.Diff, "display diffs")
-	flag.BoolVar(&cfg.Recursive, "recursive", cfg.Recursive, "recurse into sub‑directories")
-	flag.BoolVar(&cfg.Test, "test", cfg.Test, "run tests")
-	flag.BoolVar(&cfg.SortInputs, "sort-inputs", cfg.SortInputs, "alphabetize inputs in resources")
-	flag.BoolVar(&cfg.SortVars, "sort-vars", cfg.SortVars, "alphabetize variables in variable blocks")
-	flag.Parse()
+	flags := flag.NewFlagSet("tffmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&cfg.Write, "write", cfg.Write, "write result to source file(s)")
+	flags.Int64Var(&cfg.MaxFileSize, "max-file-size", cfg.MaxFileSize, "leave files larger than `BYTES` alone, 0 for no limit")
+	flags.StringVar(&cfg.Oversized, "oversized", cfg.Oversized, "what to do with files over -max-file-size: skip (with a warning) or error")
+	flags.BoolVar(&cfg.Stdout, "stdout", cfg.Stdout, "print the formatted content of each file to stdout instead of writing it")
+	flags.BoolVar(&cfg.JSON, "json", cfg.JSON, "print the files -list lists, or the diffs of -diff, as one JSON array at the end of the run")
+	flags.BoolVar(&cfg.ListOptions, "list-options", cfg.ListOptions, "print a JSON description of every flag and exit")
+	flags.BoolVar(&cfg.Progress, "progress", cfg.Progress, "show how many files are done on stderr while formatting, when it is a terminal")
+	flags.BoolVar(&cfg.Touch, "touch", cfg.Touch, "set the modification time of every processed file to now, changed or not")
+	flags.BoolVar(&cfg.NoFollowSymlinks, "no-follow-symlink-write", cfg.NoFollowSymlinks, "skip symlinked files instead of writing through to their targets")
+	flags.BoolVar(&cfg.Atomic, "atomic", cfg.Atomic, "only write files when every file formatted successfully")
+	flags.BoolVar(&cfg.Check, "check", cfg.Check, "check if files are already formatted, exiting with 3 when any file named or found in a directory is not")
+	flags.BoolVar(&cfg.IgnoreSortInCheck, "ignore-sort-in-check", cfg.IgnoreSortInCheck, "with -check, don't fail on content that is only out of sort order")
+	flags.BoolVar(&cfg.IgnoreCommentChanges, "ignore-comment-changes", cfg.IgnoreCommentChanges, "with -check, don't fail on comments that only differ in style, like // for #")
+	flags.BoolVar(&cfg.IgnoreTrailingNewlines, "ignore-trailing-newlines", cfg.IgnoreTrailingNewlines, "with -check, don't fail on files that only end in a different number of newlines")
+	flags.BoolVar(&cfg.List, "list", cfg.List, "list files whose formatting differs")
+	flags.BoolVar(&cfg.ListUnchanged, "list-unchanged", cfg.ListUnchanged, "list files that are already formatted instead of those that differ")
+	flags.BoolVar(&cfg.VerifyNoop, "verify-noop", cfg.VerifyNoop, "list files that formatting leaves as they are, checking that formatting twice does too, and warn about any file formatting twice changes")
+	flags.BoolVar(&cfg.GroupList, "group-list", cfg.GroupList, "group listed files under a header for their directory")
+	flags.BoolVar(&cfg.NoColor, "no-color", cfg.NoColor, "don't color -group-list headers")
+	flags.BoolVar(&cfg.Diff, "diff", cfg.Diff, "display diffs")
+	flags.StringVar(&cfg.DiffFormat, "diff-format", cfg.DiffFormat, "diff format: unified, context, side-by-side or git")
+	flags.BoolVar(&cfg.DiffByBlock, "diff-by-block", cfg.DiffByBlock, "with -diff, group hunks under the name of the block they change")
+	flags.BoolVar(&cfg.FirstDiffLine, "first-diff-line", cfg.FirstDiffLine, "instead of listing unformatted files, print path:LINE: needs formatting for the first line that changes")
+	flags.BoolVar(&cfg.Explain, "explain", cfg.Explain, "list the reasons each changed file was reformatted")
+	flags.BoolVar(&cfg.Recursive, "recursive", cfg.Recursive, "recurse into sub‑directories")
+	flags.IntVar(&cfg.Parallel, "parallel", cfg.Parallel, "format up to `N` files concurrently (default $TFFMT_PARALLEL, or GOMAXPROCS)")
+	flags.BoolVar(&cfg.Verify, "verify", cfg.Verify, "report an error instead of writing output that no longer parses")
+	flags.BoolVar(&cfg.FailFast, "fail-fast", cfg.FailFast, "stop at the first file that fails or needs formatting")
+	flags.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose, "explain why files need formatting")
+	flags.StringVar(&cfg.LabelMatch, "label-match", cfg.LabelMatch, "only format top-level blocks whose name label matches this regular expression")
+	flags.BoolVar(&cfg.Test, "test", cfg.Test, "run tests")
+	flags.BoolVar(&cfg.SortInputs, "sort-inputs", cfg.SortInputs, "alphabetize inputs in resources")
+	flags.BoolVar(&cfg.SortVars, "sort-vars", cfg.SortVars, "alphabetize variables in variable blocks")
+	flags.BoolVar(&cfg.SortData, "sort-data", cfg.SortData, "sort data blocks by their type and name")
+	flags.BoolVar(&cfg.SortOutputs, "sort-outputs", cfg.SortOutputs, "sort output blocks by their name")
+	flags.BoolVar(&cfg.SortCaseInsensitive, "sort-case-insensitive", cfg.SortCaseInsensitive, "sort names ignoring case, putting names that differ only in case in byte order")
+	flags.BoolVar(&cfg.Modernize, "modernize", cfg.Modernize, "rewrite deprecated list() and map() calls as [] and {} expressions")
+	flags.BoolVar(&cfg.MergeLocals, "merge-locals", cfg.MergeLocals, "merge all locals blocks into the first one")
+	flags.BoolVar(&cfg.SortLocals, "sort-locals", cfg.SortLocals, "alphabetize values in locals blocks")
+	flags.BoolVar(&cfg.CanonicalDynamic, "canonical-dynamic", cfg.CanonicalDynamic, "order dynamic blocks as for_each, iterator, labels, then content")
+	flags.BoolVar(&cfg.CanonicalLifecycle, "canonical-lifecycle", cfg.CanonicalLifecycle, "order lifecycle blocks as create_before_destroy, prevent_destroy, ignore_changes, replace_triggered_by, then conditions")
+	flags.BoolVar(&cfg.PreserveInterleaving, "preserve-interleaving", cfg.PreserveInterleaving, "when reordering the content of blocks, keep attributes and nested blocks interleaved as written")
+	flags.BoolVar(&cfg.PreserveBlankLinesInComments, "preserve-blank-lines-in-comments", cfg.PreserveBlankLinesInComments, "keep runs of blank lines between the lines of a comment instead of collapsing them")
+	flags.BoolVar(&cfg.NoSortCommentBlocks, "no-sort-comment-blocks", cfg.NoSortCommentBlocks, "don't sort blocks across standalone comments, sorting each comment-delimited section on its own")
+	flags.BoolVar(&cfg.NormalizeEOLInStrings, "normalize-eol-within-strings", cfg.NormalizeEOLInStrings, "turn CRLF line endings inside heredocs and strings into LF")
+	flags.IntVar(&cfg.FoldDescriptions, "fold-descriptions", cfg.FoldDescriptions, "write variable descriptions longer than `N` characters as heredocs")
+	flags.IntVar(&cfg.WrapCalls, "wrap-calls", cfg.WrapCalls, "put each argument of function calls on lines wider than `N` columns on its own line")
+	flags.BoolVar(&cfg.FixHeredocIndent, "fix-heredoc-indent", cfg.FixHeredocIndent, "reindent <<- heredoc bodies to the enclosing block")
+	flags.IntVar(&cfg.Indent, "indent", cfg.Indent, "indent nested content by `N` spaces per level")
+	flags.IntVar(&cfg.BlockSpacing, "block-spacing", cfg.BlockSpacing, "end blocks with `N` newlines: 2 leaves a blank line between them, 1 none")
+	flags.BoolVar(&cfg.NormalizeEscapes, "normalize-escapes", cfg.NormalizeEscapes, `write unnecessary \u escapes in strings, like \u0041, as the character itself`)
+	flags.BoolVar(&cfg.NormalizeProviderVersions, "normalize-provider-versions", cfg.NormalizeProviderVersions, `write the version constraints in required_providers in the ">= 1.0, < 2.0" style`)
+	flags.BoolVar(&cfg.NormalizeListSpacing, "normalize-list-spacing", cfg.NormalizeListSpacing, `write single-line lists as ["a", "b"]`)
+	flags.BoolVar(&cfg.SkipUnsafePasses, "skip-unsafe-passes", cfg.SkipUnsafePasses, "skip rules that can't safely format a file, with a warning, instead of applying them anyway")
+	flags.StringVar(&cfg.CacheFile, "cache", cfg.CacheFile, "skip files whose git blob SHA is recorded in this cache file as formatted under the same options")
+	flags.BoolVar(&cfg.SkipGenerated, "skip-generated", cfg.SkipGenerated, "skip files whose first line matches -generated-marker")
+	flags.StringVar(&cfg.GeneratedMarker, "generated-marker", cfg.GeneratedMarker, "regular expression matching the first line of generated files")
+	flags.BoolVar(&cfg.Tofu, "tofu", cfg.Tofu, "also format OpenTofu .tofu files, and accept .tofu.json files, which like .tf.json are left as they are")
+	flags.StringVar(&cfg.ConfigFile, "config", cfg.ConfigFile, "read settings from `FILE` instead of searching for .tffmt.yml")
+	flags.StringVar(&cfg.ConfigKey, "config-key", cfg.ConfigKey, "read settings from the table at this dot-separated `KEY` of the config file")
+	flags.BoolVar(&cfg.SaveConfig, "save-config", cfg.SaveConfig, "write the settings in effect to .tffmt.yml instead of formatting")
+	flags.BoolVar(&cfg.Force, "force", cfg.Force, "with -save-config, overwrite an existing .tffmt.yml")
+	flags.StringVar(&cfg.Learn, "learn", cfg.Learn, "infer the conventions of the terraform files in `DIR` and write them to DIR/.tffmt.yml")
+	flags.StringVar(&cfg.DumpAST, "dump-ast", cfg.DumpAST, "print the block and attribute structure of `FILE` and exit")
+	flags.BoolVar(&cfg.CheckNaming, "check-naming", cfg.CheckNaming, "report resource and data labels that don't match -naming-pattern")
+	flags.StringVar(&cfg.NamingPattern, "naming-pattern", cfg.NamingPattern, "regular expression resource and data labels must match")
+	flags.BoolVar(&cfg.CheckBackend, "check-backend", cfg.CheckBackend, "report unknown arguments in backend blocks of known types")
+	flags.BoolVar(&cfg.CheckEmptyDefaults, "check-empty-defaults", cfg.CheckEmptyDefaults, `warn about variables with default = "", which may be meant as no default or null`)
+	flags.BoolVar(&cfg.CheckAlignment, "check-alignment", cfg.CheckAlignment, "warn about lines whose alignment depends on the tab width")
+	flags.BoolVar(&cfg.CheckProviderRefs, "check-provider-refs", cfg.CheckProviderRefs, "report resources and data sources whose provider refers to an undeclared alias")
+	flags.StringVar(&cfg.SinceCommit, "since-commit", cfg.SinceCommit, "in directories, only process files added or modified since the git `REF`")
+	flags.BoolVar(&cfg.OnlyTracked, "only-tracked", cfg.OnlyTracked, "in directories, skip files that aren't tracked by git")
+	flags.DurationVar(&cfg.ModifiedSince, "modified-since", cfg.ModifiedSince, "when walking directories, only process files modified within this `DURATION` (e.g. 5m)")
+	flags.StringVar(&cfg.CommentStyle, "comment-style", cfg.CommentStyle, "line comment style to normalize to: hash (#) or slash (//)")
+	flags.BoolVar(&cfg.StatsJSON, "stats-json", cfg.StatsJSON, "print a JSON count of the files each formatting rule changed")
+	flags.BoolVar(&cfg.Count, "count", cfg.Count, "print the number of files and unformatted files per directory")
+	flags.BoolVar(&cfg.CountLines, "count-lines", cfg.CountLines, "print the total number of lines formatting adds and removes, and the files it changes")
+	flags.BoolVar(&cfg.CountAttributes, "count-attributes", cfg.CountAttributes, "report the blocks with the most attributes instead of formatting")
+	flags.IntVar(&cfg.Top, "top", cfg.Top, "with -count-attributes, only report the `N` largest blocks")
+	flags.StringVar(&cfg.AlignScope, "align-scope", cfg.AlignScope, "align attributes per blank-line group or across the whole block: group or block")
+	flags.BoolVar(&cfg.AlignTrailingComments, "align-trailing-comments", cfg.AlignTrailingComments, "align the trailing comments of each block to one column")
+	flags.BoolVar(&cfg.Watch, "watch", cfg.Watch, "keep running and format files again whenever they change")
+	flags.DurationVar(&cfg.Debounce, "debounce", cfg.Debounce, "with -watch, format changes arriving within this `DURATION` of each other as one batch")
+	flags.BoolVar(&cfg.Stdin, "stdin", cfg.Stdin, "format standard input and write the result to standard output, like passing -")
+	flags.BoolVar(&cfg.StdinMultiple, "fmt-stdin-multiple", cfg.StdinMultiple, "format NUL-separated documents from standard input, writing them NUL-separated to standard output")
+	flags.StringVar(&cfg.StdinErrors, "stdin-errors", cfg.StdinErrors, "with -fmt-stdin-multiple, what to write for a document that fails: marker, echo or abort")
+	flags.BoolVar(&cfg.LSP, "lsp", cfg.LSP, "serve document formatting to editors over the language server protocol on standard input and output")
+	flags.BoolVar(&cfg.Fragment, "fragment", cfg.Fragment, "with -stdin, keep the input's trailing newlines instead of forcing two")
+	flags.StringVar(&cfg.WriteLock, "write-lock", cfg.WriteLock, "write the sha256 of every formatted file to the lock `FILE`")
+	flags.StringVar(&cfg.VerifyLock, "verify-lock", cfg.VerifyLock, "fail when a file's formatted sha256 doesn't match the lock `FILE`")
+	flags.BoolVar(&cfg.MemStats, "mem-stats", cfg.MemStats, "print memory allocation statistics to stderr at the end of the run")
+	flags.StringVar(&cfg.Report, "report", cfg.Report, "write a summary report of the run to `FILE`")
+	flags.Func("preserve-attrs", "comma-separated attribute `names` whose values are left exactly as written", func(s string) error {
+		cfg.PreserveAttrs = splitList(s)
+		return nil
+	})
+	flags.Func("include", "in directories, only process files whose path below the directory matches this `PATTERN` (repeatable)", func(s string) error {
+		cfg.Include = append(cfg.Include, s)
+		return nil
+	})
+	flags.Func("vars-first", "comma-separated variable `names` -sort-vars puts first, in this order", func(s string) error {
+		cfg.VarsFirst = splitList(s)
+		return nil
+	})
+	flags.Func("attribute-order", "comma-separated attribute `names` -sort-inputs puts in this order, with * for the rest, sorted by name", func(s string) error {
+		cfg.AttributeOrder = splitList(s)
+		return nil
+	})
+	flags.Func("normalize-bool-attrs", "comma-separated `names` of empty blocks to rewrite as name = true attributes", func(s string) error {
+		cfg.NormalizeBoolAttrs = splitList(s)
+		return nil
+	})
+	flags.Func("no-reorder-types", "comma-separated block `types` that keep their position and content order when sorting", func(s string) error {
+		cfg.NoReorderTypes = splitList(s)
+		return nil
+	})
+	flags.Func("config-search-paths", "`paths`, separated like $PATH, of settings files or directories to look for .tffmt.yml in before the usual locations", func(s string) error {
+		cfg.ConfigSearchPaths = filepath.SplitList(s)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if cfg.ListOptions {
+		return listOptions(flags)
+	}
+
+	// Track which flags were explicitly set by the user
+	passedFlags := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		passedFlags[f.Name] = true
+	})
+	flagCfg := cfg.Clone()
 
 	// Load settings from config file
-	settings, err := config.LoadSettings()
+	settings, err := loadSettings()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to load settings: %v\n", err)
+		fmt.Fprintf(stderr, "Warning: Failed to load settings: %v\n", err)
 	} else {
-		// Track which flags were explicitly set by the user
-		passedFlags := make(map[string]bool)
-		flag.Visit(func(f *flag.Flag) {
-			passedFlags[f.Name] = true
-		})
-
 		// Update config with settings from file
 		config.ApplySettings(cfg, settings, passedFlags)
 	}
+	setupNested(flagCfg, passedFlags)
 
-	// Get paths from arguments
-	paths := flag.Args()
-	if len(paths) == 0 {
-		paths = []string{"."}
+	colorOutput = !cfg.NoColor && os.Getenv("NO_COLOR") == "" && isTerminal(stdoutW)
+	setupProgress(stderrW)
+
+	if cfg.DumpAST != "" {
+		return dumpAST(cfg.DumpAST)
+	}
+
+	if cfg.Learn != "" {
+		return learn(cfg.Learn)
+	}
+
+	if cfg.CommentStyle != "hash" && cfg.CommentStyle != "slash" {
+		fmt.Fprintf(stderr, "tffmt: invalid -comment-style %q: must be hash or slash\n", cfg.CommentStyle)
+		return 1
+	}
+
+	if !validDiffFormat(cfg.DiffFormat) {
+		fmt.Fprintf(stderr, "tffmt: invalid -diff-format %q: must be unified, context, side-by-side or git\n", cfg.DiffFormat)
+		return 1
+	}
+
+	if cfg.StdinErrors != "marker" && cfg.StdinErrors != "echo" && cfg.StdinErrors != "abort" {
+		fmt.Fprintf(stderr, "tffmt: invalid -stdin-errors %q: must be marker, echo or abort\n", cfg.StdinErrors)
+		return 1
+	}
+
+	if cfg.AlignScope != "group" && cfg.AlignScope != "block" {
+		fmt.Fprintf(stderr, "tffmt: invalid -align-scope %q: must be group or block\n", cfg.AlignScope)
+		return 1
+	}
+
+	for _, pattern := range cfg.Include {
+		if !doublestar.ValidatePattern(pattern) {
+			fmt.Fprintf(stderr, "tffmt: invalid -include pattern %q\n", pattern)
+			return 1
+		}
+	}
+
+	if cfg.Indent < 1 {
+		fmt.Fprintf(stderr, "tffmt: invalid -indent %d: must be at least 1\n", cfg.Indent)
+		return 1
+	}
+
+	if cfg.Parallel < 0 {
+		fmt.Fprintf(stderr, "tffmt: invalid -parallel %d: must not be negative\n", cfg.Parallel)
+		return 1
+	}
+
+	if cfg.BlockSpacing != 1 && cfg.BlockSpacing != 2 {
+		fmt.Fprintf(stderr, "Warning: Ignoring invalid block-spacing %d: must be 1 or 2\n", cfg.BlockSpacing)
+		cfg.BlockSpacing = 2
+	}
+
+	if cfg.Oversized != "skip" && cfg.Oversized != "error" {
+		fmt.Fprintf(stderr, "tffmt: invalid -oversized %q: must be skip or error\n", cfg.Oversized)
+		return 1
+	}
+
+	if _, err := regexp.Compile(cfg.LabelMatch); err != nil {
+		fmt.Fprintln(stderr, "tffmt: invalid -label-match:", err)
+		return 1
+	}
+
+	if err := setupLint(); err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	if err := setupGenerated(); err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+
+	// Only save settings that passed validation
+	if cfg.SaveConfig {
+		return saveConfig(".tffmt.yml")
+	}
+
+	// Files printed to stdout are left as they are
+	if cfg.Stdout {
+		if passedFlags["write"] && cfg.Write {
+			fmt.Fprintln(stderr, "tffmt: -stdout and -write can't be used together")
+			return 2
+		}
+		cfg.Write = false
+		cfg.List = false
+	}
+
+	// Counting attributes is read-only analysis
+	if cfg.CountAttributes {
+		cfg.Write = false
+		cfg.List = false
+	}
+
+	if cfg.VerifyLock != "" {
+		lockWanted, err = readLock(cfg.VerifyLock)
+		if err != nil {
+			fmt.Fprintln(stderr, "tffmt:", err)
+			return 1
+		}
+	}
+
+	if cfg.LSP {
+		return serveLSP(stdinR, stdout)
+	}
+	// "-" stands for standard input, like in terraform fmt
+	if slices.Contains(flags.Args(), "-") && flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "tffmt: - (standard input) can't be combined with other paths")
+		return 2
+	}
+	if cfg.Stdin || flags.Arg(0) == "-" {
+		return formatStdin(stdinR, stdout)
+	}
+	if cfg.StdinMultiple {
+		return formatStdinMultiple(stdinR, stdout)
 	}
 
-	// Process paths
+	if cfg.CacheFile != "" {
+		cache, err = loadCheckCache(cfg.CacheFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: Failed to load cache, continuing without it: %v\n", err)
+		}
+	}
+
+	// Get paths from arguments, expanding any glob patterns ourselves
+	// since not every shell does it for us
 	exit := 0
+	paths, err := expandPaths(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		exit = 1
+	}
+	if len(flags.Args()) == 0 {
+		paths = []string{"."}
+	}
+	if len(paths) == 1 {
+		info, err := os.Stat(paths[0])
+		stdoutHeaders = err == nil && info.IsDir()
+	} else {
+		stdoutHeaders = len(paths) > 1
+	}
+
+	// Files named outright must be formatted or be JSON configuration,
+	// while those a glob matched may be anything
+	named := map[string]bool{}
+	for _, arg := range flags.Args() {
+		named[arg] = true
+	}
+
+	// Files are formatted together on the worker pool, a run of them at a
+	// time so output keeps the order of the arguments. Unlike in
+	// directories, a file that fails doesn't stop the others.
+	var files []string
+	flushFiles := func() (stop bool) {
+		err := processFiles(files, &exit, true)
+		files = nil
+		return errors.Is(err, errFailFast) || errors.Is(err, errInterrupted)
+	}
+
+	// Process paths, finishing the files in progress on Ctrl-C
+	stopInterrupts := catchInterrupts()
+	stopped := false
 	for _, p := range paths {
 		info, err := os.Stat(p)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			exit = 1
+		if err == nil && !info.IsDir() && isTerraformFile(p) {
+			files = append(files, p)
 			continue
 		}
+		if stopped = flushFiles(); stopped {
+			break
+		}
 
-		if info.IsDir() {
-			if err := walkDir(p); err != nil {
-				fmt.Fprintln(os.Stderr, err)
+		if err != nil {
+			msg, code := pathError(p, err)
+			fmt.Fprintln(stderr, "tffmt:", msg)
+			exit = code
+		} else if info.IsDir() {
+			err := processDir(p, &exit)
+			if errors.Is(err, errFailFast) || errors.Is(err, errInterrupted) {
+				stopped = true
+				break
+			}
+			if err != nil {
+				fmt.Fprintln(stderr, err)
 				exit = 1
 			}
-		} else if filepath.Ext(p) == ".tf" {
-			changed, err := processFile(p)
-			_ = handleResult(changed, err, &exit)
+		} else if named[p] && !isJSONConfig(p) {
+			fmt.Fprintf(stderr, "tffmt: %s: not a .tf file and not a directory\n", p)
+			exit = 2
+		}
+	}
+	if !stopped {
+		flushFiles()
+	}
+	finishProgress()
+
+	if cfg.Atomic {
+		finishAtomic(&exit)
+	}
+	finishLock(&exit)
+	printSummaries()
+
+	if cache != nil {
+		if err := cache.save(); err != nil {
+			fmt.Fprintf(stderr, "Warning: Failed to save cache: %v\n", err)
 		}
 	}
-	os.Exit(exit)
+
+	stopInterrupts()
+	if interrupted.Load() {
+		return 130
+	}
+	if cfg.Watch {
+		return watch(paths, nil)
+	}
+	return exit
 }
 
 // main calls Main for local development
@@ -84,9 +477,228 @@ func main() {
 	Main()
 }
 
+// loadSettings loads the settings from the -config file, or from the
+// first .tffmt.yml found when none was given
+func loadSettings() (config.Settings, error) {
+	if cfg.ConfigFile != "" {
+		return config.LoadSettingsFile(cfg.ConfigFile, cfg.ConfigKey)
+	}
+	settingsCache = config.NewSettingsCache(cfg.ConfigKey, cfg.ConfigSearchPaths...)
+	return settingsCache.SettingsFor(".")
+}
+
+// dumpAST prints the structure of a single file and returns the exit code
+func dumpAST(path string) int {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	if err := formatter.DumpAST(stdout, content, path); err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	return 0
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// formatStdin formats everything read from r and writes the result to w,
+// returning the exit code. Under -check and -diff nothing is written but
+// the diff, which names the input <stdin>.
+func formatStdin(r io.Reader, w io.Writer) int {
+	orig, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+
+	// Echo input that doesn't parse instead of running the byte-level
+	// passes over it, so piping through tffmt never loses content
+	compare := cfg.Check || cfg.Diff
+	res := formatInput("<stdin>", orig)
+	if res.Err != nil {
+		if !compare {
+			w.Write(orig)
+		}
+		fmt.Fprintln(stderr, "tffmt:", res.Err)
+		return 1
+	}
+	if compare {
+		if res.Changed && cfg.Diff {
+			showDiff(w, res.Path, res.Orig, res.Formatted)
+		}
+		if res.Changed && cfg.Check {
+			return 3
+		}
+		return 0
+	}
+	if _, err := w.Write(res.Formatted); err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	return 0
+}
+
+// formatStdinMultiple formats the NUL-separated documents read from r one at
+// a time, writing each result to w with the NUL that ended it, and returns
+// the exit code. A document that fails is replaced by an error marker, or
+// under -stdin-errors echoed as it is or ends the stream.
+func formatStdinMultiple(r io.Reader, w io.Writer) int {
+	in := bufio.NewReader(r)
+	exit := 0
+	for i := 1; ; i++ {
+		doc, err := in.ReadBytes(0)
+		if err != nil && !errors.Is(err, io.EOF) {
+			fmt.Fprintln(stderr, "tffmt:", err)
+			return 1
+		}
+		if len(doc) == 0 {
+			return exit
+		}
+		content, ended := bytes.CutSuffix(doc, []byte{0})
+
+		res := formatInput(fmt.Sprintf("<stdin #%d>", i), content)
+		out := res.Formatted
+		if res.Err != nil {
+			fmt.Fprintln(stderr, "tffmt:", res.Err)
+			exit = 1
+			switch cfg.StdinErrors {
+			case "abort":
+				return exit
+			case "echo":
+				out = res.Orig
+			case "marker":
+				out = []byte("tffmt: error: " + res.Err.Error() + "\n")
+			}
+		}
+		w.Write(out)
+		if ended {
+			w.Write([]byte{0})
+		}
+		if err != nil {
+			return exit
+		}
+	}
+}
+
+// formatInput formats content read from a stream rather than a file, named
+// name in messages. Content that doesn't parse fails without running the
+// byte-level passes over it, keeping it as it is.
+func formatInput(name string, orig []byte) FileResult {
+	if _, diags := hclwrite.ParseConfig(orig, name, hcl.InitialPos); diags.HasErrors() {
+		return FileResult{Path: name, Orig: orig, Formatted: orig, Err: diags}
+	}
+	return formatContent(name, orig)
+}
+
+// isTerraformFile reports whether path has an extension tffmt formats
+func isTerraformFile(path string) bool {
+	_, ok := formatterFor(path)
+	return ok
+}
+
+// included reports whether path, found under the directory root, matches
+// one of the -include patterns, or whether there are none. Patterns match
+// the slash-separated path relative to root.
+func included(root, path string) bool {
+	if len(cfg.Include) == 0 {
+		return true
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range cfg.Include {
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// expandPaths expands path arguments containing glob metacharacters,
+// including "**" for matching any number of directories. Arguments without
+// metacharacters are passed through untouched. Patterns that match nothing
+// are reported as an error, but the remaining paths are still returned.
+func expandPaths(args []string) ([]string, error) {
+	var paths []string
+	var unmatched []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[{") {
+			paths = append(paths, arg)
+			continue
+		}
+
+		matches, err := doublestar.FilepathGlob(arg)
+		if err != nil {
+			return paths, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			unmatched = append(unmatched, arg)
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+	if len(unmatched) > 0 {
+		return paths, fmt.Errorf("no files match pattern %s", strings.Join(unmatched, ", "))
+	}
+	return paths, nil
+}
+
+// pathError describes why the path argument p couldn't be read, with the
+// exit code for it: 2 for a path that was mistyped, 1 for one that exists
+// but can't be read
+func pathError(p string, err error) (string, int) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return p + ": path does not exist", 2
+	case errors.Is(err, fs.ErrPermission):
+		return p + ": permission denied", 1
+	}
+	return err.Error(), 1
+}
+
+// processDir processes the terraform files in a directory, or only those
+// changed since -since-commit when it is set. Under -only-tracked, files
+// git doesn't track are left out.
+func processDir(root string, exit *int) error {
+	if cfg.SinceCommit == "" {
+		return walkDir(root, exit)
+	}
+	changed, err := changedSinceCommit(root, cfg.SinceCommit)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, path := range changed {
+		if included(root, path) {
+			paths = append(paths, path)
+		}
+	}
+	if cfg.OnlyTracked {
+		if paths, err = filterTracked(root, paths); err != nil {
+			return err
+		}
+	}
+	return processFiles(paths, exit, false)
+}
+
 // walkDir recursively processes terraform files in a directory
-func walkDir(root string) error {
-	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+func walkDir(root string, exit *int) error {
+	cutoff := time.Now().Add(-cfg.ModifiedSince)
+	var paths []string
+	walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -96,68 +708,304 @@ func walkDir(root string) error {
 			}
 			return nil
 		}
-		if filepath.Ext(path) == ".tf" {
-			changed, err := processFile(path)
+		if !isTerraformFile(path) || !included(root, path) {
+			return nil
+		}
+		if cfg.ModifiedSince > 0 {
+			info, err := d.Info()
 			if err != nil {
 				return err
 			}
-			// Don't stop the walk on changed files, let handleResult determine exit code
-			_ = handleResult(changed, nil, new(int))
+			if info.ModTime().Before(cutoff) {
+				return nil
+			}
 		}
+		paths = append(paths, path)
 		return nil
 	})
-}
 
-// processFile formats a single terraform file
-func processFile(path string) (changed bool, err error) {
-	orig, err := os.ReadFile(path)
-	if err != nil {
-		return false, err
+	if cfg.OnlyTracked {
+		var err error
+		if paths, err = filterTracked(root, paths); err != nil {
+			return err
+		}
 	}
 
-	formatted, changed := formatterInst.FormatFile(orig)
+	if walkErr == nil && len(paths) == 0 && cfg.Verbose {
+		fmt.Fprintf(stderr, "%s: note: no terraform files found\n", root)
+	}
 
-	// Handle flags for output
-	if cfg.List && changed {
-		fmt.Println(path)
+	// Don't stop on changed files, let handleResult determine the exit
+	// code, but stop at the first file that fails
+	if err := processFiles(paths, exit, false); err != nil {
+		return err
 	}
-	if cfg.Diff && changed {
-		showDiff(path, orig, formatted)
+	return walkErr
+}
+
+// stopEarly reports whether -fail-fast should stop processing after res
+func stopEarly(res FileResult) bool {
+	return cfg.FailFast && (res.Err != nil || res.Changed || lint.Failing(res.Issues))
+}
+
+// processFile reads and formats a single terraform file, writing the
+// result back when requested, and bumps its mtime under -touch. It does
+// not print anything.
+func processFile(path string) FileResult {
+	res := formatFile(path)
+	if cfg.Touch && res.Err == nil && !res.Skipped {
+		now := time.Now()
+		res.Err = os.Chtimes(path, now, now)
 	}
-	if cfg.Write && changed && !cfg.Check {
+	return res
+}
+
+// formatFile reads and formats a single terraform file, writing the result
+// back when requested
+func formatFile(path string) FileResult {
+	if cache != nil && cache.hit(path) {
+		return FileResult{Path: path, Cached: true}
+	}
+
+	// Don't even read files too large to be hand-written configuration
+	if cfg.MaxFileSize > 0 {
 		info, err := os.Stat(path)
 		if err != nil {
-			return changed, err
+			return FileResult{Path: path, Err: err}
+		}
+		if info.Size() > cfg.MaxFileSize {
+			if cfg.Oversized == "error" {
+				return FileResult{Path: path, Err: fmt.Errorf("%s: %d bytes is over -max-file-size %d", path, info.Size(), cfg.MaxFileSize)}
+			}
+			fmt.Fprintf(stderr, "Warning: Skipping %s: %d bytes is over -max-file-size %d\n", path, info.Size(), cfg.MaxFileSize)
+			return FileResult{Path: path, Skipped: true}
 		}
-		err = os.WriteFile(path, formatted, info.Mode().Perm())
+	}
+
+	if generatedMarker != nil {
+		generated, err := isGenerated(path)
 		if err != nil {
-			return changed, err
+			return FileResult{Path: path, Err: err}
+		}
+		if generated {
+			return FileResult{Path: path, Skipped: true}
 		}
 	}
-	return changed, nil
+
+	writing := cfg.Write && !cfg.Check
+	if writing {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return FileResult{Path: path, Err: err}
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if cfg.NoFollowSymlinks {
+				fmt.Fprintf(stderr, "Warning: Skipping symlink %s\n", path)
+				return FileResult{Path: path, Skipped: true}
+			}
+			target, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return FileResult{Path: path, Err: err}
+			}
+			fmt.Fprintf(stderr, "Warning: %s is a symlink, writing to %s\n", path, target)
+		}
+	}
+
+	orig, err := os.ReadFile(path)
+	if err != nil {
+		return FileResult{Path: path, Err: err}
+	}
+
+	res := formatContent(path, orig)
+	if cache != nil && !res.Changed && res.Err == nil && !lint.Failing(res.Issues) {
+		cache.record(path)
+	}
+	if writing && res.Changed {
+		info, err := os.Stat(path)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		if cfg.Atomic {
+			if res.Err == nil {
+				deferWrite(path, res.Formatted, info.Mode().Perm())
+			}
+			return res
+		}
+		if err := os.WriteFile(path, res.Formatted, info.Mode().Perm()); err != nil {
+			res.Err = err
+		}
+	}
+	return res
 }
 
-// showDiff displays the formatting changes in unified diff format
-func showDiff(path string, a, b []byte) {
-	u := difflib.UnifiedDiff{
-		A:        difflib.SplitLines(string(a)),
-		B:        difflib.SplitLines(string(b)),
-		FromFile: path + " (orig)",
-		ToFile:   path + " (fmt)",
-		Context:  3,
+// formatContent formats the content of a terraform file without touching disk
+func formatContent(path string, orig []byte) FileResult {
+	// Content without a registered extension, like stdin, is terraform
+	var f Formatter = formatterInst
+	if registered, ok := formatterFor(path); ok {
+		f = registered
+	}
+
+	// A directive in the file overrides the settings for it
+	hclFmt, isHCL := f.(*formatter.Formatter)
+	if isHCL {
+		var err error
+		if hclFmt, err = directiveFormatter(hclFmt, orig); err != nil {
+			return FileResult{Path: path, Orig: orig, Formatted: orig, Err: fmt.Errorf("%s: %w", path, err)}
+		}
+		f = hclFmt
+	}
+
+	// Only the hcl formatter knows to skip rules it can't trust
+	var (
+		formatted []byte
+		fileStats formatter.Stats
+		warnings  []formatter.Warning
+	)
+	if isHCL {
+		formatted, fileStats, warnings = hclFmt.FormatWarnings(orig)
+	} else {
+		formatted, fileStats = f.FormatStats(orig)
+	}
+
+	// Keep the file as it is rather than writing out broken HCL
+	if cfg.Verify {
+		if err := formatter.Verify(orig, formatted); err != nil {
+			return FileResult{Path: path, Orig: orig, Formatted: orig, Err: fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+
+	res := FileResult{
+		Path:      path,
+		Changed:   changed(orig, formatted),
+		Orig:      orig,
+		Formatted: formatted,
+		Stats:     fileStats,
+		Warnings:  warnings,
+	}
+	if cfg.VerifyNoop {
+		var again []byte
+		if isHCL {
+			again, _, _ = hclFmt.FormatWarnings(formatted)
+		} else {
+			again, _ = f.FormatStats(formatted)
+		}
+		res.Unstable = !bytes.Equal(again, formatted)
+		res.NoOp = bytes.Equal(orig, formatted) && !res.Unstable
+	}
+	if isHCL && cfg.Verbose && res.Changed && fileStats["preprocess"] > 0 && hclFmt.ParenSplitOnly(orig) {
+		res.Note = `the only change is splitting "({" and "})" onto separate lines`
+	}
+	if isHCL && cfg.Explain && res.Changed {
+		res.Reasons = hclFmt.Explain(orig)
 	}
-	text, _ := difflib.GetUnifiedDiffString(u)
-	fmt.Print(text)
+	if lintEnabled() {
+		res.Issues, res.Err = lintFile(path, orig)
+	}
+
+	// An -atomic run must not write anything when a file fails to parse
+	if isHCL && cfg.Atomic && res.Err == nil {
+		if _, err := lint.Parse(orig, path); err != nil {
+			res.Err = err
+		}
+	}
+	return res
 }
 
-// handleResult processes errors and sets exit codes
-func handleResult(changed bool, err error, exit *int) error {
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "tffmt:", err)
+// changed reports whether formatting changed orig. Under -check with
+// -ignore-trailing-newlines, the newlines ending the file don't count.
+func changed(orig, formatted []byte) bool {
+	if cfg.Check && cfg.IgnoreTrailingNewlines {
+		return !bytes.Equal(bytes.TrimRight(orig, "\n"), bytes.TrimRight(formatted, "\n"))
+	}
+	return !bytes.Equal(orig, formatted)
+}
+
+// printResult writes the -list and -diff output for a single result
+func printResult(res FileResult) {
+	if res.Skipped {
+		return
+	}
+	if cfg.Stdout {
+		printFormatted(res)
+	}
+	if !res.Changed {
+		if cfg.VerifyNoop {
+			if res.NoOp {
+				listPath(res.Path)
+			}
+		} else if cfg.ListUnchanged {
+			listPath(res.Path)
+		}
+		return
+	}
+	if cfg.FirstDiffLine {
+		fmt.Fprintf(stdout, "%s:%d: needs formatting\n", res.Path, firstDiffLine(res.Orig, res.Formatted))
+	} else if cfg.List && !cfg.ListUnchanged && !cfg.VerifyNoop {
+		listPath(res.Path)
+	}
+	if res.Note != "" {
+		fmt.Fprintf(stderr, "%s: note: %s\n", res.Path, res.Note)
+	}
+	if cfg.Explain {
+		fmt.Fprintf(stdout, "%s:\n", res.Path)
+		for _, reason := range res.Reasons {
+			fmt.Fprintf(stdout, "  %s\n", reason)
+		}
+	}
+	if cfg.Diff && cfg.JSON {
+		recordDiff(res)
+	} else if cfg.Diff {
+		showDiff(stdout, res.Path, res.Orig, res.Formatted)
+	}
+}
+
+// printFormatted prints the formatted content of a file for -stdout, under
+// a "==> path <==" header when several files are printed
+func printFormatted(res FileResult) {
+	if stdoutHeaders {
+		fmt.Fprintf(stdout, "==> %s <==\n", res.Path)
+	}
+	stdout.Write(res.Formatted)
+}
+
+// showDiff displays the formatting changes in the -diff-format format, or
+// grouped by block under -diff-by-block
+func showDiff(w io.Writer, path string, a, b []byte) {
+	if cfg.DiffByBlock {
+		fmt.Fprint(w, blockDiff(path, a, b))
+		return
+	}
+	fmt.Fprint(w, diffText(cfg.DiffFormat, path, a, b))
+}
+
+// handleResult prints a file result, processes errors and sets exit codes
+func handleResult(res FileResult, exit *int) error {
+	recordSummary(res)
+	if res.Err != nil {
+		fmt.Fprintln(stderr, "tffmt:", res.Err)
+		*exit = 1
+		return res.Err
+	}
+	for _, warning := range res.Warnings {
+		fmt.Fprintf(stderr, "Warning: %s: %s\n", res.Path, warning)
+	}
+	if res.Unstable {
+		fmt.Fprintf(stderr, "Warning: %s: formatting again changes the formatted content\n", res.Path)
+	}
+	printResult(res)
+	for _, issue := range res.Issues {
+		fmt.Fprintln(stderr, issue)
+	}
+	if lint.Failing(res.Issues) {
+		*exit = 1
+	}
+	if err := checkLock(res); err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
 		*exit = 1
-		return err
 	}
-	if changed && cfg.Check && *exit == 0 {
+	if res.Changed && cfg.Check && *exit == 0 {
 		*exit = 3 // terraform fmt's "needs formatting" code
 	}
 	return nil
@@ -2,9 +2,13 @@ package tffmt
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/krewenki/tffmt/pkg/config"
 	"github.com/krewenki/tffmt/pkg/formatter"
@@ -70,14 +74,14 @@ func TestProcessFile(t *testing.T) {
 			formatterInst = formatter.New(cfg) // Initialize the formatter with the config
 
 			// Process the file
-			changed, err := processFile(filePath)
-			if err != nil {
-				t.Fatal(err)
+			res := processFile(filePath)
+			if res.Err != nil {
+				t.Fatal(res.Err)
 			}
 
 			// Check if the change detection is correct
-			if changed != tt.expectChange {
-				t.Errorf("processFile() changed = %v, want %v", changed, tt.expectChange)
+			if res.Changed != tt.expectChange {
+				t.Errorf("processFile() changed = %v, want %v", res.Changed, tt.expectChange)
 			}
 
 			// Read the processed file
@@ -94,6 +98,39 @@ func TestProcessFile(t *testing.T) {
 	}
 }
 
+func TestFormatContent(t *testing.T) {
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	formatterInst = formatter.New(cfg)
+
+	orig := []byte("resource \"example\" \"test\" {\nfoo = bar\n}")
+	res := formatContent("example.tf", orig)
+
+	if res.Path != "example.tf" {
+		t.Errorf("formatContent() path = %q, want %q", res.Path, "example.tf")
+	}
+	if !res.Changed {
+		t.Errorf("formatContent() changed = false, want true")
+	}
+	if res.Err != nil {
+		t.Errorf("formatContent() err = %v, want nil", res.Err)
+	}
+	if !bytes.Equal(res.Orig, orig) {
+		t.Errorf("formatContent() orig = %q, want %q", res.Orig, orig)
+	}
+	want := "resource \"example\" \"test\" {\n  foo = bar\n}\n\n"
+	if string(res.Formatted) != want {
+		t.Errorf("formatContent() formatted = %q, want %q", res.Formatted, want)
+	}
+}
+
 func TestCheckFlag(t *testing.T) {
 	// Create a temporary directory for test files
 	tmpDir, err := os.MkdirTemp("", "tffmt-check-test")
@@ -125,8 +162,7 @@ func TestCheckFlag(t *testing.T) {
 	formatterInst = formatter.New(cfg) // Initialize the formatter with the config
 
 	exit := 0
-	changed, err := processFile(filePath)
-	handleResult(changed, err, &exit)
+	handleResult(processFile(filePath), &exit)
 
 	if exit != 3 {
 		t.Errorf("handleResult() with check=true and unformatted file should set exit=3, got %d", exit)
@@ -142,6 +178,171 @@ func TestCheckFlag(t *testing.T) {
 	}
 }
 
+// TestCheckDirectory verifies -check fails with exit 3 on drifted files
+// found by walking a directory, like on files named on the command line
+func TestCheckDirectory(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantExit int
+	}{
+		{"formatted", "resource \"example\" \"test\" {\n  foo = bar\n}\n\n", 0},
+		{"drifted", "resource \"example\" \"test\" {foo = bar}", 3},
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, "main.tf"), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg = config.NewConfig()
+			cfg.Write = false
+			cfg.List = false
+			cfg.Check = true
+			formatterInst = formatter.New(cfg)
+
+			exit := 0
+			if err := walkDir(tmpDir, &exit); err != nil {
+				t.Fatal(err)
+			}
+			if exit != tt.wantExit {
+				t.Errorf("walkDir() exit = %d, want %d", exit, tt.wantExit)
+			}
+		})
+	}
+}
+
+// TestIgnoreSortInCheck verifies -ignore-sort-in-check only fails -check on
+// whitespace drift, not on ordering
+func TestIgnoreSortInCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		ignoreSort bool
+		expectExit int
+	}{
+		{"unsorted with strict check", "resource \"example\" \"test\" {\n  zone = 1\n  ami  = 2\n}\n\n", false, 3},
+		{"unsorted with relaxed check", "resource \"example\" \"test\" {\n  zone = 1\n  ami  = 2\n}\n\n", true, 0},
+		{"misformatted with relaxed check", "resource \"example\" \"test\" {\nzone = 1\n  ami  = 2\n}\n\n", true, 3},
+		{"unmerged locals with strict check", "locals {\n  a = 1\n}\n\nlocals {\n  b = 2\n}\n\n", false, 3},
+		{"unmerged locals with relaxed check", "locals {\n  a = 1\n}\n\nlocals {\n  b = 2\n}\n\n", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.List = false
+			cfg.Check = true
+			cfg.SortInputs = true
+			cfg.MergeLocals = true
+			cfg.IgnoreSortInCheck = tt.ignoreSort
+			formatterInst = formatter.New(cfg)
+
+			exit := 0
+			_ = handleResult(formatContent("main.tf", []byte(tt.content)), &exit)
+			if exit != tt.expectExit {
+				t.Errorf("handleResult() exit = %d, want %d", exit, tt.expectExit)
+			}
+		})
+	}
+}
+
+// TestIgnoreCommentChanges verifies -ignore-comment-changes passes -check
+// on files that only differ in comment style
+func TestIgnoreCommentChanges(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		ignoreComment bool
+		expectExit    int
+	}{
+		{"slash comment with strict check", "// The test resource\nresource \"example\" \"test\" {\n  foo = bar // inline\n}\n\n", false, 3},
+		{"slash comment with relaxed check", "// The test resource\nresource \"example\" \"test\" {\n  foo = bar // inline\n}\n\n", true, 0},
+		{"misformatted with relaxed check", "// The test resource\nresource \"example\" \"test\" {\nfoo = bar\n}\n\n", true, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.List = false
+			cfg.Check = true
+			cfg.IgnoreCommentChanges = tt.ignoreComment
+			formatterInst = formatter.New(cfg)
+
+			exit := 0
+			_ = handleResult(formatContent("main.tf", []byte(tt.content)), &exit)
+			if exit != tt.expectExit {
+				t.Errorf("handleResult() exit = %d, want %d", exit, tt.expectExit)
+			}
+		})
+	}
+}
+
+// TestIgnoreTrailingNewlines verifies -ignore-trailing-newlines only lets
+// -check pass files whose sole difference is the newlines they end in
+func TestIgnoreTrailingNewlines(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		ignoreNewlines bool
+		expectExit     int
+	}{
+		{"single newline with strict check", "resource \"example\" \"test\" {\n  foo = bar\n}\n", false, 3},
+		{"single newline with relaxed check", "resource \"example\" \"test\" {\n  foo = bar\n}\n", true, 0},
+		{"misformatted with relaxed check", "resource \"example\" \"test\" {\nfoo = bar\n}\n", true, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.List = false
+			cfg.Check = true
+			cfg.IgnoreTrailingNewlines = tt.ignoreNewlines
+			formatterInst = formatter.New(cfg)
+
+			exit := 0
+			_ = handleResult(formatContent("main.tf", []byte(tt.content)), &exit)
+			if exit != tt.expectExit {
+				t.Errorf("handleResult() exit = %d, want %d", exit, tt.expectExit)
+			}
+		})
+	}
+}
+
 func TestHandleResult(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -167,7 +368,7 @@ func TestHandleResult(t *testing.T) {
 			cfg.Check = tc.checkFlag
 
 			exit := 0
-			err := handleResult(tc.changed, tc.inputError, &exit)
+			err := handleResult(FileResult{Changed: tc.changed, Err: tc.inputError}, &exit)
 
 			if (err != nil) != (tc.inputError != nil) {
 				t.Errorf("handleResult() error = %v, want %v", err, tc.inputError)
@@ -246,14 +447,14 @@ func TestSortInputsFlag(t *testing.T) {
 			formatterInst = formatter.New(cfg)
 
 			// Process the file
-			changed, err := processFile(filePath)
-			if err != nil {
-				t.Fatal(err)
+			res := processFile(filePath)
+			if res.Err != nil {
+				t.Fatal(res.Err)
 			}
 
 			// Check if the file was modified as expected
-			if changed != tc.expectModified {
-				t.Errorf("processFile() changed = %v, want %v", changed, tc.expectModified)
+			if res.Changed != tc.expectModified {
+				t.Errorf("processFile() changed = %v, want %v", res.Changed, tc.expectModified)
 			}
 
 			// Read the processed file
@@ -279,3 +480,789 @@ func TestSortInputsFlag(t *testing.T) {
 		})
 	}
 }
+
+// TestExpandPaths verifies glob patterns in path arguments are expanded
+func TestExpandPaths(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tffmt-glob-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := []string{
+		filepath.Join(tmpDir, "main.tf"),
+		filepath.Join(tmpDir, "modules", "vpc", "main.tf"),
+		filepath.Join(tmpDir, "modules", "vpc", "README.md"),
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := expandPaths([]string{filepath.Join(tmpDir, "**", "*.tf")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{files[0], files[1]}
+	if len(paths) != len(expected) {
+		t.Fatalf("expandPaths() = %v, want %v", paths, expected)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("expandPaths()[%d] = %q, want %q", i, paths[i], expected[i])
+		}
+	}
+
+	// Plain paths are passed through untouched, even if they don't exist
+	paths, err = expandPaths([]string{"does-not-exist.tf"})
+	if err != nil || len(paths) != 1 || paths[0] != "does-not-exist.tf" {
+		t.Errorf("expandPaths() = %v, %v, want [does-not-exist.tf], nil", paths, err)
+	}
+
+	// Patterns without matches are reported
+	if _, err := expandPaths([]string{filepath.Join(tmpDir, "*.tfvars")}); err == nil {
+		t.Errorf("expandPaths() with unmatched pattern should return an error")
+	}
+}
+
+// TestTofuFiles verifies .tofu files are only formatted when enabled, and
+// that OpenTofu-specific blocks format cleanly
+func TestTofuFiles(t *testing.T) {
+	input := `terraform {
+encryption {
+key_provider "pbkdf2" "main" {
+passphrase = var.passphrase
+}
+method "aes_gcm" "main" {
+keys = key_provider.pbkdf2.main
+}
+state {
+method = method.aes_gcm.main
+enforced = true
+}
+}
+}`
+	expected := `terraform {
+  encryption {
+    key_provider "pbkdf2" "main" {
+      passphrase = var.passphrase
+    }
+
+    method "aes_gcm" "main" {
+      keys = key_provider.pbkdf2.main
+    }
+
+    state {
+      method   = method.aes_gcm.main
+      enforced = true
+    }
+
+  }
+
+}
+
+`
+
+	for _, tofu := range []bool{false, true} {
+		tmpDir, err := os.MkdirTemp("", "tffmt-tofu-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		filePath := filepath.Join(tmpDir, "encryption.tofu")
+		if err := os.WriteFile(filePath, []byte(input), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Save original config and restore it afterwards
+		origCfg := cfg
+		origFormatter := formatterInst
+		defer func() {
+			cfg = origCfg
+			formatterInst = origFormatter
+		}()
+
+		cfg = config.NewConfig()
+		cfg.List = false
+		cfg.Tofu = tofu
+		formatterInst = formatter.New(cfg)
+
+		exit := 0
+		if err := walkDir(tmpDir, &exit); err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := input
+		if tofu {
+			want = expected
+		}
+		if string(output) != want {
+			t.Errorf("with tofu=%v, got:\n%s\nwant:\n%s", tofu, output, want)
+		}
+	}
+}
+
+// TestModifiedSince verifies only recently modified files are processed during a walk
+func TestModifiedSince(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tffmt-modified-since-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	content := "resource \"example\" \"test\" {foo = bar}"
+	recent := filepath.Join(tmpDir, "recent.tf")
+	old := filepath.Join(tmpDir, "old.tf")
+	for _, p := range []string{recent, old} {
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hourAgo := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(old, hourAgo, hourAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	cfg.List = false
+	cfg.ModifiedSince = 5 * time.Minute
+	formatterInst = formatter.New(cfg)
+
+	exit := 0
+	if err := walkDir(tmpDir, &exit); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(recent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) == content {
+		t.Errorf("recently modified file was not formatted")
+	}
+
+	output, err = os.ReadFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != content {
+		t.Errorf("file modified an hour ago was formatted: %q", output)
+	}
+}
+
+// TestInclude verifies only files matching an -include pattern are
+// processed when walking a directory
+func TestInclude(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "resource \"example\" \"test\" {foo = bar}"
+	paths := map[string]bool{
+		"main.tf":                true,
+		"modules/vpc/main.tf":    false,
+		"modules/db/outputs.tf":  false,
+		"environments/prod/a.tf": true,
+	}
+	for path := range paths {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	cfg.List = false
+	cfg.Recursive = true
+	cfg.Include = []string{"modules/**"}
+	formatterInst = formatter.New(cfg)
+
+	exit := 0
+	if err := walkDir(tmpDir, &exit); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, untouched := range paths {
+		output, err := os.ReadFile(filepath.Join(tmpDir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (string(output) == content) != untouched {
+			t.Errorf("%s formatted = %v, want %v", path, string(output) != content, !untouched)
+		}
+	}
+}
+
+// TestStatsAggregation verifies rule counts are summed across files
+func TestStatsAggregation(t *testing.T) {
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStats := stats
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stats = origStats
+	}()
+
+	cfg = config.NewConfig()
+	cfg.List = false
+	formatterInst = formatter.New(cfg)
+	stats = formatter.Stats{}
+
+	corpus := []string{
+		"resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+		"resource \"example\" \"test\" {\nfoo = bar\n}",
+		"block1 {}\nblock2 {}\n",
+	}
+	exit := 0
+	for i, content := range corpus {
+		_ = handleResult(formatContent(fmt.Sprintf("file%d.tf", i), []byte(content)), &exit)
+	}
+
+	expected := map[string]int{
+		"preprocess":        0,
+		"comment_style":     0,
+		"hcl_format":        1,
+		"for_spacing":       0,
+		"blank_lines":       1,
+		"resource_spacing":  0,
+		"trailing_newlines": 2,
+	}
+	if len(stats) != len(expected) {
+		t.Errorf("stats = %v, want %v", stats, expected)
+	}
+	for rule, n := range expected {
+		if stats[rule] != n {
+			t.Errorf("stats[%s] = %d, want %d", rule, stats[rule], n)
+		}
+	}
+}
+
+// TestFormatStdinFragment verifies fragments keep their trailing newline state
+func TestFormatStdinFragment(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment bool
+		input    string
+		expected string
+	}{
+		{"fragment without newline", true, "foo = bar", "foo = bar"},
+		{"fragment with newline", true, "foo   = bar\n", "foo = bar\n"},
+		{"whole file", false, "foo = bar", "foo = bar\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.Stdin = true
+			cfg.Fragment = tt.fragment
+			formatterInst = formatter.New(cfg)
+
+			var out bytes.Buffer
+			if exit := formatStdin(strings.NewReader(tt.input), &out); exit != 0 {
+				t.Fatalf("formatStdin() exit = %d, want 0", exit)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("formatStdin() = %q, want %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormatStdinInvalid verifies input that isn't HCL is echoed unchanged
+// with an error instead of being formatted
+func TestFormatStdinInvalid(t *testing.T) {
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStderr := stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stderr = origStderr
+	}()
+
+	cfg = config.NewConfig()
+	cfg.Stdin = true
+	formatterInst = formatter.New(cfg)
+	var errOut bytes.Buffer
+	stderr = &errOut
+
+	input := "{\n  \"name\":   \"not hcl\",\n  \"list\": [1,2]\n}\n"
+	var out bytes.Buffer
+	if exit := formatStdin(strings.NewReader(input), &out); exit != 1 {
+		t.Fatalf("formatStdin() exit = %d, want 1", exit)
+	}
+	if out.String() != input {
+		t.Errorf("formatStdin() = %q, want the input %q", out.String(), input)
+	}
+	if !strings.Contains(errOut.String(), "<stdin>:1") {
+		t.Errorf("stderr = %q, want the parse error", errOut.String())
+	}
+}
+
+// TestFormatStdinMultiple verifies NUL-separated documents are formatted
+// one by one, with the one that doesn't parse handled by -stdin-errors
+func TestFormatStdinMultiple(t *testing.T) {
+	input := "variable \"a\" {\ntype = string\n}\x00variable \"b\" {\x00output \"c\" {\nvalue = 1\n}\x00"
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{
+			mode:     "marker",
+			expected: "variable \"a\" {\n  type = string\n}\n\n\x00tffmt: error: <stdin #2>:1,14-15: Unclosed configuration block; There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file.\n\x00output \"c\" {\n  value = 1\n}\n\n\x00",
+		},
+		{
+			mode:     "echo",
+			expected: "variable \"a\" {\n  type = string\n}\n\n\x00variable \"b\" {\x00output \"c\" {\n  value = 1\n}\n\n\x00",
+		},
+		{
+			mode:     "abort",
+			expected: "variable \"a\" {\n  type = string\n}\n\n\x00",
+		},
+	}
+
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStderr := stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stderr = origStderr
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg = config.NewConfig()
+			cfg.StdinMultiple = true
+			cfg.StdinErrors = tt.mode
+			formatterInst = formatter.New(cfg)
+			var errOut bytes.Buffer
+			stderr = &errOut
+
+			var out bytes.Buffer
+			if exit := formatStdinMultiple(strings.NewReader(input), &out); exit != 1 {
+				t.Errorf("formatStdinMultiple() exit = %d, want 1", exit)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("formatStdinMultiple() = %q, want %q", out.String(), tt.expected)
+			}
+			if !strings.Contains(errOut.String(), "<stdin #2>") {
+				t.Errorf("stderr = %q, want the error of the second document", errOut.String())
+			}
+		})
+	}
+
+	// Documents that format but then fail a check are echoed as read. The
+	// provider check fails reading the broken link next to them.
+	t.Run("echo after formatting", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.Symlink(filepath.Join(dir, "missing.tf"), filepath.Join(dir, "broken.tf")); err != nil {
+			t.Fatal(err)
+		}
+		t.Chdir(dir)
+		cfg = config.NewConfig()
+		cfg.StdinMultiple = true
+		cfg.StdinErrors = "echo"
+		cfg.CheckProviderRefs = true
+		formatterInst = formatter.New(cfg)
+		providerAliases.dirs = map[string]map[string]bool{}
+		defer func() { providerAliases.dirs = map[string]map[string]bool{} }()
+		stderr = &bytes.Buffer{}
+
+		doc := "variable \"b\" {\ntype = string\n}\n"
+		var out bytes.Buffer
+		if exit := formatStdinMultiple(strings.NewReader(doc), &out); exit != 1 {
+			t.Errorf("formatStdinMultiple() exit = %d, want 1", exit)
+		}
+		if out.String() != doc {
+			t.Errorf("formatStdinMultiple() = %q, want %q", out.String(), doc)
+		}
+	})
+}
+
+// TestFailFast verifies -fail-fast stops the walk at the first drifted file
+func TestFailFast(t *testing.T) {
+	tests := []struct {
+		name      string
+		failFast  bool
+		wantFirst bool
+		wantLast  bool
+	}{
+		{"scan everything", false, true, true},
+		{"stop at first drift", true, true, false},
+	}
+
+	content := "resource \"example\" \"test\" {foo = bar}"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			first := filepath.Join(tmpDir, "a.tf")
+			last := filepath.Join(tmpDir, "b.tf")
+			for _, p := range []string{first, last} {
+				if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.List = false
+			cfg.FailFast = tt.failFast
+			formatterInst = formatter.New(cfg)
+
+			exit := 0
+			err := walkDir(tmpDir, &exit)
+			if tt.failFast && !errors.Is(err, errFailFast) {
+				t.Errorf("walkDir() error = %v, want %v", err, errFailFast)
+			}
+			if !tt.failFast && err != nil {
+				t.Errorf("walkDir() error = %v", err)
+			}
+
+			for path, want := range map[string]bool{first: tt.wantFirst, last: tt.wantLast} {
+				output, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if formatted := string(output) != content; formatted != want {
+					t.Errorf("%s formatted = %v, want %v", filepath.Base(path), formatted, want)
+				}
+			}
+		})
+	}
+}
+
+// captureStdout returns everything fn writes to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	var out bytes.Buffer
+	origStdout := stdout
+	stdout = &out
+	defer func() { stdout = origStdout }()
+
+	fn()
+	return out.String()
+}
+
+// TestListUnchanged verifies -list-unchanged prints only the files that
+// are already formatted
+func TestListUnchanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"clean.tf":     "resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+		"messy.tf":     "resource \"example\" \"test\" {foo = bar}",
+		"sub/clean.tf": "variable \"name\" {\n  type = string\n}\n\n",
+		"sub/messy.tf": "variable \"name\" {type = string}",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	cfg.Check = true
+	cfg.Recursive = true
+	cfg.ListUnchanged = true
+	formatterInst = formatter.New(cfg)
+
+	exit := 0
+	output := captureStdout(t, func() {
+		if err := walkDir(tmpDir, &exit); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	expected := filepath.Join(tmpDir, "clean.tf") + "\n" + filepath.Join(tmpDir, "sub", "clean.tf") + "\n"
+	if output != expected {
+		t.Errorf("-list-unchanged output =\n%s\nwant:\n%s", output, expected)
+	}
+}
+
+// unstableFormatter leaves content alone the first time it sees it and
+// appends a comment after that, like a rule that isn't idempotent
+type unstableFormatter struct{ seen map[string]bool }
+
+func (f unstableFormatter) FormatStats(content []byte) ([]byte, formatter.Stats) {
+	if f.seen[string(content)] {
+		return append(content, "# again\n"...), nil
+	}
+	f.seen[string(content)] = true
+	return content, nil
+}
+
+// TestVerifyNoop verifies -verify-noop lists the files formatting leaves
+// alone twice over, and neither drifted files nor unstable formatting
+func TestVerifyNoop(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"clean.tf":    "resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+		"messy.tf":    "resource \"example\" \"test\" {foo = bar}",
+		"unstable.tq": "foo = bar\n",
+		"growing.tr":  "foo = bar\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStderr := stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stderr = origStderr
+		delete(formatters, ".tq")
+		delete(formatters, ".tr")
+	}()
+
+	cfg = config.NewConfig()
+	cfg.Check = true
+	cfg.VerifyNoop = true
+	formatterInst = formatter.New(cfg)
+	unstable := unstableFormatter{seen: map[string]bool{}}
+	registerFormatter(".tq", func(string) Formatter { return unstable }, always)
+	registerFormatter(".tr", func(string) Formatter { return growingFormatter{} }, always)
+	var errOut bytes.Buffer
+	stderr = &errOut
+
+	exit := 0
+	output := captureStdout(t, func() {
+		if err := walkDir(tmpDir, &exit); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	expected := filepath.Join(tmpDir, "clean.tf") + "\n"
+	if output != expected {
+		t.Errorf("-verify-noop output =\n%s\nwant:\n%s", output, expected)
+	}
+
+	// Unstable formatting is reported, also for files formatting changes
+	var want string
+	for _, name := range []string{"growing.tr", "unstable.tq"} {
+		want += "Warning: " + filepath.Join(tmpDir, name) + ": formatting again changes the formatted content\n"
+	}
+	if errOut.String() != want {
+		t.Errorf("-verify-noop stderr = %q, want %q", errOut.String(), want)
+	}
+}
+
+// growingFormatter appends a comment every time, like a rule that changes
+// its own output
+type growingFormatter struct{}
+
+func (growingFormatter) FormatStats(content []byte) ([]byte, formatter.Stats) {
+	return append(append([]byte(nil), content...), "# again\n"...), nil
+}
+
+// TestParenSplitNote verifies -verbose explains when the paren split is
+// the only change to a file
+func TestParenSplitNote(t *testing.T) {
+	parenOnly := "resource \"a\" \"b\" {\n  policy = jsonencode({\n    a = 1\n  })\n}\n\n"
+	tests := []struct {
+		name     string
+		content  string
+		verbose  bool
+		wantNote bool
+	}{
+		{"paren split only", parenOnly, true, true},
+		{"not verbose", parenOnly, false, false},
+		{"other changes too", "resource \"a\" \"b\" {\nname = \"x\"\n  policy = jsonencode({\n    a = 1\n  })\n}\n\n", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.Verbose = tt.verbose
+			formatterInst = formatter.New(cfg)
+
+			res := formatContent("example.tf", []byte(tt.content))
+			if !res.Changed {
+				t.Fatalf("formatContent() changed = false, want true")
+			}
+			if (res.Note != "") != tt.wantNote {
+				t.Errorf("formatContent() note = %q, want note: %v", res.Note, tt.wantNote)
+			}
+		})
+	}
+}
+
+// TestMaxFileSize verifies files over -max-file-size are left unread,
+// skipped with a warning or failed under -oversized=error
+func TestMaxFileSize(t *testing.T) {
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	tests := []struct {
+		name        string
+		maxSize     int64
+		oversized   string
+		expectExit  int
+		expectWarn  bool
+		expectWrite bool
+	}{
+		{"no limit", 0, "skip", 0, false, true},
+		{"under the limit", 1000, "skip", 0, false, true},
+		{"over the limit", 10, "skip", 0, true, false},
+		{"over the limit as an error", 10, "error", 1, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "main.tf")
+			if err := os.WriteFile(path, []byte(unformatted), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// Save original state and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			origStderr := stderr
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+				stderr = origStderr
+			}()
+
+			cfg = config.NewConfig()
+			cfg.List = false
+			cfg.MaxFileSize = tt.maxSize
+			cfg.Oversized = tt.oversized
+			formatterInst = formatter.New(cfg)
+			var errOut bytes.Buffer
+			stderr = &errOut
+
+			exit := 0
+			_ = handleResult(processFile(path), &exit)
+			if exit != tt.expectExit {
+				t.Errorf("exit = %d, want %d (stderr: %s)", exit, tt.expectExit, errOut.String())
+			}
+			if warned := strings.Contains(errOut.String(), "Warning: Skipping"); warned != tt.expectWarn {
+				t.Errorf("stderr = %q, want a warning: %v", errOut.String(), tt.expectWarn)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if written := string(content) != unformatted; written != tt.expectWrite {
+				t.Errorf("file written = %v, want %v", written, tt.expectWrite)
+			}
+		})
+	}
+}
+
+// TestTouch verifies -touch bumps the mtime of changed and unchanged files
+// alike, and that unchanged files keep it otherwise
+func TestTouch(t *testing.T) {
+	files := map[string]string{
+		"changed.tf":   "resource \"example\" \"test\" {foo = bar}",
+		"unchanged.tf": "resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+	}
+
+	for _, touch := range []bool{false, true} {
+		t.Run(fmt.Sprintf("touch=%v", touch), func(t *testing.T) {
+			tmpDir := t.TempDir()
+			old := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+			for name, content := range files {
+				path := filepath.Join(tmpDir, name)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.Touch = touch
+			formatterInst = formatter.New(cfg)
+
+			for name := range files {
+				path := filepath.Join(tmpDir, name)
+				if res := processFile(path); res.Err != nil {
+					t.Fatal(res.Err)
+				}
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				bumped := info.ModTime().After(old)
+				if want := touch || name == "changed.tf"; bumped != want {
+					t.Errorf("%s mtime = %v, want bumped: %v", name, info.ModTime(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package tffmt
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// memStatsLine summarizes the memory use of the run for -mem-stats
+func memStatsLine() string {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return fmt.Sprintf("tffmt: mem: total_alloc=%s heap_inuse=%s num_gc=%d",
+		mebibytes(m.TotalAlloc), mebibytes(m.HeapInuse), m.NumGC)
+}
+
+// mebibytes formats a byte count in MiB
+func mebibytes(n uint64) string {
+	return fmt.Sprintf("%.1fMiB", float64(n)/(1<<20))
+}
@@ -0,0 +1,67 @@
+package tffmt
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// nested formats files below a different .tffmt.yml than the one the run
+// started with according to that file's settings
+var nested = struct {
+	sync.Mutex
+	enabled     bool
+	rootFile    string
+	flagCfg     *config.Config
+	passedFlags map[string]bool
+	formatters  map[string]*formatter.Formatter
+}{formatters: map[string]*formatter.Formatter{}}
+
+// setupNested enables nested settings files. flagCfg is the configuration
+// set by flags alone, which the settings of each nested file are applied to.
+// Nested files are ignored when -config names the settings file to use.
+func setupNested(flagCfg *config.Config, passedFlags map[string]bool) {
+	nested.Lock()
+	defer nested.Unlock()
+	nested.enabled = cfg.ConfigFile == "" && settingsCache != nil
+	if !nested.enabled {
+		return
+	}
+	nested.rootFile = settingsCache.ConfigFileFor(".")
+	nested.flagCfg = flagCfg
+	nested.passedFlags = passedFlags
+	nested.formatters = map[string]*formatter.Formatter{}
+}
+
+// nestedFormatter returns the formatter for the settings file nearest to
+// path, or nil when that is the file the run started with
+func nestedFormatter(path string) *formatter.Formatter {
+	nested.Lock()
+	defer nested.Unlock()
+	if !nested.enabled {
+		return nil
+	}
+	dir := filepath.Dir(path)
+	file := settingsCache.ConfigFileFor(dir)
+	if file == nested.rootFile {
+		return nil
+	}
+	if f, ok := nested.formatters[file]; ok {
+		return f
+	}
+
+	settings, err := settingsCache.SettingsFor(dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: Failed to load settings, using those of the run instead: %v\n", err)
+		nested.formatters[file] = nil
+		return nil
+	}
+	c := nested.flagCfg.Clone()
+	config.ApplySettings(c, settings, nested.passedFlags)
+	f := formatter.New(c)
+	nested.formatters[file] = f
+	return f
+}
@@ -0,0 +1,116 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNestedSettings verifies files are formatted with the settings of the
+// nearest .tffmt.yml, so subtrees with different settings are each honored
+func TestNestedSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	unsorted := "resource \"example\" \"test\" {\n  zone = 1\n  ami  = 2\n}\n\n"
+	sorted := "resource \"example\" \"test\" {\n  ami  = 2\n  zone = 1\n}\n\n"
+	files := map[string]string{
+		".tffmt.yml":            "sort-inputs: false\n",
+		"main.tf":               unsorted,
+		"services/a/.tffmt.yml": "sort-inputs: true\n",
+		"services/a/main.tf":    unsorted,
+		"services/a/db/main.tf": unsorted,
+		"services/b/main.tf":    unsorted,
+	}
+	for path, content := range files {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	t.Chdir(tmpDir)
+	var out, errOut bytes.Buffer
+	if exit := Run([]string{"-recursive", "-list=false", "."}, strings.NewReader(""), &out, &errOut); exit != 0 {
+		t.Fatalf("Run() = %d, want 0 (stderr: %s)", exit, errOut.String())
+	}
+
+	want := map[string]string{
+		"main.tf":               unsorted,
+		"services/a/main.tf":    sorted,
+		"services/a/db/main.tf": sorted,
+		"services/b/main.tf":    unsorted,
+	}
+	for path, expected := range want {
+		content, err := os.ReadFile(filepath.Join(tmpDir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s = %q, want %q", path, content, expected)
+		}
+	}
+}
+
+// TestConfigSearchPaths verifies a settings file on -config-search-paths
+// wins over the .tffmt.yml of the directory being formatted
+func TestConfigSearchPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	unsorted := "resource \"example\" \"test\" {\n  zone = 1\n  ami  = 2\n}\n\n"
+	files := map[string]string{
+		"project/.tffmt.yml": "sort-inputs: false\n",
+		"project/main.tf":    unsorted,
+		"custom/.tffmt.yml":  "sort-inputs: true\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	t.Chdir(filepath.Join(tmpDir, "project"))
+	search := filepath.Join(tmpDir, "missing") + string(filepath.ListSeparator) + filepath.Join(tmpDir, "custom")
+	tests := []struct {
+		name     string
+		args     []string
+		wantExit int
+	}{
+		{"default locations", []string{"-check", "."}, 0},
+		{"search paths", []string{"-check", "-config-search-paths", search, "."}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if exit := Run(tt.args, strings.NewReader(""), &out, &errOut); exit != tt.wantExit {
+				t.Errorf("Run(%q) = %d, want %d (stderr: %s)", tt.args, exit, tt.wantExit, errOut.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package tffmt
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// flagOption describes a command-line flag for -list-options
+type flagOption struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+}
+
+// listOptions prints a JSON description of every flag of flags, sorted by
+// name, and returns the exit code
+func listOptions(flags *flag.FlagSet) int {
+	options := []flagOption{}
+	flags.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		options = append(options, flagOption{
+			Name:    f.Name,
+			Type:    flagType(f),
+			Default: f.DefValue,
+			Usage:   usage,
+		})
+	})
+	data, err := json.MarshalIndent(options, "", "  ")
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(data))
+	return 0
+}
+
+// flagType names the type of value f takes, like bool, int or duration.
+// Flags defined with flags.Func take a list or other text, so they are
+// strings.
+func flagType(f *flag.Flag) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", f.Value), "*")
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "flag."), "Value")
+	if name == "func" {
+		return "string"
+	}
+	return name
+}
@@ -0,0 +1,51 @@
+package tffmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestListOptions verifies -list-options describes the flags with their
+// types and defaults
+func TestListOptions(t *testing.T) {
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	var out, errOut bytes.Buffer
+	if exit := Run([]string{"-list-options"}, nil, &out, &errOut); exit != 0 {
+		t.Fatalf("Run() = %d, want 0 (stderr: %s)", exit, errOut.String())
+	}
+	var options []flagOption
+	if err := json.Unmarshal(out.Bytes(), &options); err != nil {
+		t.Fatalf("output isn't JSON: %v\n%s", err, out.String())
+	}
+	byName := map[string]flagOption{}
+	for _, option := range options {
+		byName[option.Name] = option
+	}
+
+	expected := []flagOption{
+		{Name: "write", Type: "bool", Default: "true", Usage: "write result to source file(s)"},
+		{Name: "indent", Type: "int", Default: "2", Usage: "indent nested content by N spaces per level"},
+		{Name: "align-scope", Type: "string", Default: "group"},
+		{Name: "debounce", Type: "duration", Default: "200ms"},
+		{Name: "include", Type: "string", Default: ""},
+	}
+	for _, want := range expected {
+		got, ok := byName[want.Name]
+		if !ok {
+			t.Errorf("-list-options is missing %s", want.Name)
+			continue
+		}
+		if got.Type != want.Type || got.Default != want.Default || want.Usage != "" && got.Usage != want.Usage {
+			t.Errorf("-list-options %s = %+v, want %+v", want.Name, got, want)
+		}
+	}
+}
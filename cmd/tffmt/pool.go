@@ -0,0 +1,137 @@
+package tffmt
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// workerCount returns the number of files to format concurrently: the
+// -parallel flag when set, then the TFFMT_PARALLEL environment variable,
+// then GOMAXPROCS
+func workerCount() int {
+	if cfg.Parallel > 0 {
+		return cfg.Parallel
+	}
+	if env := os.Getenv("TFFMT_PARALLEL"); env != "" {
+		n, err := strconv.Atoi(env)
+		if err == nil && n > 0 {
+			return n
+		}
+		fmt.Fprintf(stderr, "Warning: Ignoring invalid TFFMT_PARALLEL %q\n", env)
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// processFiles formats paths on a pool of workers and handles the results
+// in the order of paths, so output stays deterministic. It stops handing
+// out files after the first file that fails unless keepGoing is set, after
+// an interrupt, or under -fail-fast after the first file that needs
+// formatting.
+func processFiles(paths []string, exit *int, keepGoing bool) error {
+	addProgressTotal(len(paths))
+
+	// Under -fail-fast no file past the first failure may be touched, so
+	// format one file at a time
+	if cfg.FailFast {
+		for _, path := range paths {
+			if interrupted.Load() {
+				return errInterrupted
+			}
+			res := processFile(path)
+			tickProgress()
+			if stop, err := handleInOrder(res, exit, keepGoing); stop {
+				return err
+			}
+		}
+		return nil
+	}
+
+	results := make([]chan FileResult, len(paths))
+	for i := range results {
+		results[i] = make(chan FileResult, 1)
+	}
+
+	// Feed the workers until every path is handed out or we stop early.
+	// Paths handed out after stopping are closed unprocessed.
+	next := make(chan int)
+	done := make(chan struct{})
+	var halted atomic.Bool
+	go func() {
+		defer close(next)
+		for i := range paths {
+			select {
+			case next <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range min(workerCount(), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if interrupted.Load() {
+					results[i] <- FileResult{Path: paths[i], Interrupted: true}
+					continue
+				}
+				if halted.Load() {
+					close(results[i])
+					continue
+				}
+				res := processFile(paths[i])
+				tickProgress()
+				results[i] <- res
+			}
+		}()
+	}
+
+	for i := range paths {
+		if stop, err := handleInOrder(<-results[i], exit, keepGoing); stop {
+			halted.Store(true)
+			close(done)
+			wg.Wait()
+			handleProcessed(results[i+1:], exit)
+			return err
+		}
+	}
+	close(done)
+	wg.Wait()
+	return nil
+}
+
+// handleProcessed handles the results of the files workers had already
+// processed when processing stopped early, since they may have been
+// written. Once the workers are done every such result is waiting.
+func handleProcessed(results []chan FileResult, exit *int) {
+	for _, ch := range results {
+		select {
+		case res, ok := <-ch:
+			if ok && !res.Interrupted {
+				handleResult(res, exit)
+			}
+		default:
+		}
+	}
+}
+
+// handleInOrder handles the next result and reports whether processing
+// should stop, with errFailFast when -fail-fast stopped it and
+// errInterrupted when an interrupt did. Failing files only stop it when
+// keepGoing isn't set.
+func handleInOrder(res FileResult, exit *int, keepGoing bool) (bool, error) {
+	if res.Interrupted {
+		return true, errInterrupted
+	}
+	err := handleResult(res, exit)
+	if stopEarly(res) {
+		return true, errFailFast
+	}
+	return err != nil && !keepGoing, nil
+}
@@ -0,0 +1,232 @@
+package tffmt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestWorkerCount verifies the -parallel flag takes precedence over
+// TFFMT_PARALLEL, which takes precedence over GOMAXPROCS
+func TestWorkerCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     int
+		env      string
+		expected int
+	}{
+		{"default", 0, "", runtime.GOMAXPROCS(0)},
+		{"from env", 0, "3", 3},
+		{"flag over env", 5, "3", 5},
+		{"invalid env", 0, "many", runtime.GOMAXPROCS(0)},
+		{"zero env", 0, "0", runtime.GOMAXPROCS(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			defer func() { cfg = origCfg }()
+
+			cfg = config.NewConfig()
+			cfg.Parallel = tt.flag
+			t.Setenv("TFFMT_PARALLEL", tt.env)
+
+			if n := workerCount(); n != tt.expected {
+				t.Errorf("workerCount() = %d, want %d", n, tt.expected)
+			}
+		})
+	}
+}
+
+// concurrencyFormatter leaves content alone, recording how many files it
+// was formatting at once
+type concurrencyFormatter struct {
+	active, peak *atomic.Int32
+}
+
+func (f concurrencyFormatter) FormatStats(content []byte) ([]byte, formatter.Stats) {
+	n := f.active.Add(1)
+	defer f.active.Add(-1)
+	for peak := f.peak.Load(); n > peak && !f.peak.CompareAndSwap(peak, n); peak = f.peak.Load() {
+	}
+	time.Sleep(20 * time.Millisecond)
+	return content, nil
+}
+
+// TestFileArgsInParallel verifies files named as arguments are formatted
+// on the worker pool, with output in argument order and a file that fails
+// not stopping the rest
+func TestFileArgsInParallel(t *testing.T) {
+	tmpDir := t.TempDir()
+	emptyConfig := filepath.Join(tmpDir, "empty.yml")
+	if err := os.WriteFile(emptyConfig, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	formatted, _ := formatter.New(config.NewConfig()).FormatStats([]byte(unformatted))
+
+	var args, tfFiles []string
+	for i := range 8 {
+		tf := filepath.Join(tmpDir, fmt.Sprintf("%d.tf", i))
+		content := unformatted
+		if i == 2 {
+			content = "# tffmt: indent\n" + unformatted
+		}
+		if err := os.WriteFile(tf, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		tq := filepath.Join(tmpDir, fmt.Sprintf("%d.tq", i))
+		if err := os.WriteFile(tq, []byte("a = 1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, tf, tq)
+		tfFiles = append(tfFiles, tf)
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+		delete(formatters, ".tq")
+	}()
+	var active, peak atomic.Int32
+	registerFormatter(".tq", func(string) Formatter { return concurrencyFormatter{&active, &peak} }, always)
+
+	var out, errOut bytes.Buffer
+	runArgs := append([]string{"-config", emptyConfig, "-parallel", "4"}, args...)
+	if exit := Run(runArgs, strings.NewReader(""), &out, &errOut); exit != 1 {
+		t.Errorf("Run() = %d, want 1 (stderr: %s)", exit, errOut.String())
+	}
+	if peak.Load() < 2 {
+		t.Errorf("peak files formatted at once = %d, want several", peak.Load())
+	}
+	if !strings.Contains(errOut.String(), "2.tf: invalid tffmt directive") {
+		t.Errorf("stderr = %q, want the error for 2.tf", errOut.String())
+	}
+
+	var listed []string
+	for i, tf := range tfFiles {
+		content, err := os.ReadFile(tf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			continue
+		}
+		listed = append(listed, tf)
+		if string(content) != string(formatted) {
+			t.Errorf("%s = %q, want it formatted", tf, content)
+		}
+	}
+	if want := strings.Join(listed, "\n") + "\n"; out.String() != want {
+		t.Errorf("stdout = %q, want %q", out.String(), want)
+	}
+}
+
+// TestWalkDirInParallel verifies the files of a directory are listed in
+// path order and the exit code covers every file when they are formatted
+// concurrently
+func TestWalkDirInParallel(t *testing.T) {
+	tmpDir := t.TempDir()
+	emptyConfig := filepath.Join(tmpDir, "empty.yml")
+	if err := os.WriteFile(emptyConfig, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var unformatted []string
+	for i := range 20 {
+		tf := filepath.Join(tmpDir, fmt.Sprintf("%02d.tf", i))
+		content := "resource \"example\" \"test\" {\n  foo = bar\n}\n\n"
+		if i%3 == 0 {
+			content = "resource \"example\" \"test\" {foo = bar}"
+			unformatted = append(unformatted, tf)
+		}
+		if err := os.WriteFile(tf, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	var out, errOut bytes.Buffer
+	if exit := Run([]string{"-config", emptyConfig, "-check", "-parallel", "8", tmpDir}, strings.NewReader(""), &out, &errOut); exit != 3 {
+		t.Errorf("Run() = %d, want 3 (stderr: %s)", exit, errOut.String())
+	}
+	if want := strings.Join(unformatted, "\n") + "\n"; out.String() != want {
+		t.Errorf("stdout = %q, want %q", out.String(), want)
+	}
+
+	errOut.Reset()
+	if exit := Run([]string{"-config", emptyConfig, "-parallel", "-1", tmpDir}, strings.NewReader(""), &bytes.Buffer{}, &errOut); exit != 1 {
+		t.Errorf("Run(-parallel -1) = %d, want 1", exit)
+	}
+	if !strings.Contains(errOut.String(), "invalid -parallel -1") {
+		t.Errorf("stderr = %q, want the invalid -parallel error", errOut.String())
+	}
+}
+
+// TestStopReportsProcessedFiles verifies that when a failing file stops a
+// directory, the files workers had already written are still listed
+func TestStopReportsProcessedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	emptyConfig := filepath.Join(tmpDir, "empty.yml")
+	if err := os.WriteFile(emptyConfig, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	if err := os.WriteFile(filepath.Join(tmpDir, "0.tf"), []byte("# tffmt: indent\n"+unformatted), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 6; i++ {
+		if err := os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("%d.tf", i)), []byte(unformatted), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	var out, errOut bytes.Buffer
+	args := []string{"-config", emptyConfig, "-write", "-parallel", "4", tmpDir}
+	if exit := Run(args, strings.NewReader(""), &out, &errOut); exit != 1 {
+		t.Errorf("Run() = %d, want 1 (stderr: %s)", exit, errOut.String())
+	}
+	for i := 1; i <= 6; i++ {
+		tf := filepath.Join(tmpDir, fmt.Sprintf("%d.tf", i))
+		content, err := os.ReadFile(tf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if listed := strings.Contains(out.String(), tf+"\n"); listed != (string(content) != unformatted) {
+			t.Errorf("%s written = %v, listed = %v, want the same", tf, string(content) != unformatted, listed)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package tffmt
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// progressTTY reports whether the -progress indicator can be drawn on w
+var progressTTY = isTerminal
+
+// progress counts the files finished out of those handed to the worker
+// pool, for the -progress indicator
+var progress struct {
+	sync.Mutex
+	enabled     bool
+	done, total int
+}
+
+// setupProgress enables the -progress indicator when stderr is a terminal
+// and output isn't meant for tools under -json
+func setupProgress(stderrW io.Writer) {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.enabled = cfg.Progress && !cfg.JSON && progressTTY(stderrW)
+	progress.done, progress.total = 0, 0
+}
+
+// addProgressTotal counts n more files to format
+func addProgressTotal(n int) {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.total += n
+}
+
+// tickProgress counts a finished file and redraws the indicator in place.
+// Workers call it as they finish, so the count is drawn under the lock to
+// keep it from going backwards.
+func tickProgress() {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.done++
+	if progress.enabled {
+		fmt.Fprintf(stderr, "\r\033[Kformatting: %d/%d", progress.done, progress.total)
+	}
+}
+
+// finishProgress clears the indicator once formatting is done
+func finishProgress() {
+	progress.Lock()
+	defer progress.Unlock()
+	if progress.enabled && progress.total > 0 {
+		fmt.Fprint(stderr, "\r\033[K")
+	}
+	progress.done, progress.total = 0, 0
+}
@@ -0,0 +1,87 @@
+package tffmt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := range 3 {
+		name := filepath.Join(tmpDir, fmt.Sprintf("%d.tf", i))
+		if err := os.WriteFile(name, []byte("resource \"example\" \"test\" {foo = bar}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emptyConfig := filepath.Join(t.TempDir(), "empty.yml")
+	if err := os.WriteFile(emptyConfig, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tty  bool
+		args []string
+		want []string
+	}{
+		{
+			name: "terminal",
+			tty:  true,
+			args: []string{"-progress", "-parallel", "2"},
+			want: []string{"formatting: 1/3", "formatting: 2/3", "formatting: 3/3"},
+		},
+		{
+			name: "terminal fail fast",
+			tty:  true,
+			args: []string{"-progress", "-fail-fast"},
+			want: []string{"formatting: 1/3"},
+		},
+		{
+			name: "not a terminal",
+			args: []string{"-progress"},
+		},
+		{
+			name: "not requested",
+			tty:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original state and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			origTTY := progressTTY
+			origStdout, origStderr := stdout, stderr
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+				progressTTY = origTTY
+				stdout, stderr = origStdout, origStderr
+			}()
+			progressTTY = func(io.Writer) bool { return tt.tty }
+
+			var out, errOut bytes.Buffer
+			args := append([]string{"-config", emptyConfig, "-write=false"}, tt.args...)
+			Run(append(args, tmpDir), strings.NewReader(""), &out, &errOut)
+
+			got := errOut.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, "\r\033[K"+want) {
+					t.Errorf("stderr = %q, want update %q", got, want)
+				}
+			}
+			if len(tt.want) == 0 && strings.Contains(got, "formatting:") {
+				t.Errorf("stderr = %q, want no progress", got)
+			}
+			if len(tt.want) > 0 && !strings.HasSuffix(got, "\r\033[K") {
+				t.Errorf("stderr = %q, want the progress cleared", got)
+			}
+		})
+	}
+}
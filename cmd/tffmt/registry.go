@@ -0,0 +1,131 @@
+package tffmt
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// Formatter formats the content of one kind of file
+type Formatter interface {
+	FormatStats(content []byte) ([]byte, formatter.Stats)
+}
+
+// registration is the Formatter handling a file extension
+type registration struct {
+	// formatter returns the Formatter to use for a path
+	formatter func(path string) Formatter
+
+	// enabled reports whether files with the extension are formatted under
+	// the current configuration
+	enabled func() bool
+}
+
+// formatters holds the registered Formatters, by file extension
+var formatters = map[string]registration{
+	".tf":   {hclFormatter, always},
+	".tofu": {hclFormatter, func() bool { return cfg.Tofu }},
+}
+
+// jsonConfigs holds the extensions of JSON configuration, which tffmt
+// recognises but never reformats, with whether they are recognised under
+// the current configuration
+var jsonConfigs = map[string]func() bool{
+	".tf.json":   always,
+	".tofu.json": func() bool { return cfg.Tofu },
+}
+
+// isJSONConfig reports whether path is JSON configuration, which is left as
+// it is written
+func isJSONConfig(path string) bool {
+	for ext, enabled := range jsonConfigs {
+		if strings.HasSuffix(path, ext) && enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// hclFormatter returns the formatter for native HCL syntax, configured by
+// the settings file nearest to path
+func hclFormatter(path string) Formatter {
+	f := formatterInst
+	if dirFormatter := nestedFormatter(path); dirFormatter != nil {
+		f = dirFormatter
+	}
+	if cfg.Check && (cfg.IgnoreSortInCheck || cfg.IgnoreCommentChanges) {
+		f = checkFormatter(f)
+	}
+	return f
+}
+
+// checkFormatters caches the formatters -ignore-sort-in-check and
+// -ignore-comment-changes derive, by the formatter they derive from
+var checkFormatters = struct {
+	sync.Mutex
+	derived map[*formatter.Formatter]*formatter.Formatter
+}{derived: map[*formatter.Formatter]*formatter.Formatter{}}
+
+// checkFormatter returns the formatter -check uses in place of f, built
+// once for each formatter
+func checkFormatter(f *formatter.Formatter) *formatter.Formatter {
+	checkFormatters.Lock()
+	defer checkFormatters.Unlock()
+	if derived, ok := checkFormatters.derived[f]; ok {
+		return derived
+	}
+	derived := f
+	if cfg.IgnoreSortInCheck {
+		derived = unsortedFormatter(derived)
+	}
+	if cfg.IgnoreCommentChanges {
+		derived = commentKeepingFormatter(derived)
+	}
+	checkFormatters.derived[f] = derived
+	return derived
+}
+
+// unsortedFormatter returns a formatter like f with the rules that reorder
+// content disabled, so -check only fails on whitespace drift
+func unsortedFormatter(f *formatter.Formatter) *formatter.Formatter {
+	c := f.Config.Clone()
+	c.SortInputs = false
+	c.SortVars = false
+	c.SortData = false
+	c.SortOutputs = false
+	c.SortLocals = false
+	c.MergeLocals = false
+	c.CanonicalDynamic = false
+	c.CanonicalLifecycle = false
+	return formatter.New(c)
+}
+
+// commentKeepingFormatter returns a formatter like f that leaves comments
+// as they are written, so -check doesn't fail on comment style alone
+func commentKeepingFormatter(f *formatter.Formatter) *formatter.Formatter {
+	c := f.Config.Clone()
+	c.CommentStyle = "" // neither hash nor slash, so comments aren't rewritten
+	return formatter.New(c)
+}
+
+// always is the enabled func of extensions that are always formatted
+func always() bool {
+	return true
+}
+
+// registerFormatter makes fn the Formatter for files with extension ext
+func registerFormatter(ext string, fn func(path string) Formatter, enabled func() bool) {
+	formatters[ext] = registration{fn, enabled}
+}
+
+// formatterFor returns the Formatter for path, reporting false when tffmt
+// doesn't format files like it
+func formatterFor(path string) (Formatter, bool) {
+	reg, ok := formatters[filepath.Ext(path)]
+	if !ok || !reg.enabled() {
+		return nil, false
+	}
+	return reg.formatter(path), true
+}
@@ -0,0 +1,108 @@
+package tffmt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// upperFormatter is a test Formatter that upper-cases its input
+type upperFormatter struct{}
+
+func (upperFormatter) FormatStats(content []byte) ([]byte, formatter.Stats) {
+	return bytes.ToUpper(content), formatter.Stats{"upper": 1}
+}
+
+// TestFormatterFor verifies the Formatter is chosen by file extension
+func TestFormatterFor(t *testing.T) {
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		delete(formatters, ".upper")
+	}()
+
+	cfg = config.NewConfig()
+	formatterInst = formatter.New(cfg)
+	registerFormatter(".upper", func(string) Formatter { return upperFormatter{} }, always)
+
+	tests := []struct {
+		path      string
+		tofu      bool
+		ok        bool
+		wantHCL   bool
+		wantUpper bool
+	}{
+		{path: "main.tf", ok: true, wantHCL: true},
+		{path: "main.tofu", ok: false},
+		{path: "main.tofu", tofu: true, ok: true, wantHCL: true},
+		{path: "main.tf.json", ok: false},
+		{path: "main.tofu.json", tofu: true, ok: false},
+		{path: "README.md", ok: false},
+		{path: "notes.upper", ok: true, wantUpper: true},
+	}
+
+	for _, tt := range tests {
+		cfg.Tofu = tt.tofu
+		f, ok := formatterFor(tt.path)
+		if ok != tt.ok {
+			t.Errorf("formatterFor(%q) with tofu=%v ok = %v, want %v", tt.path, tt.tofu, ok, tt.ok)
+			continue
+		}
+		if _, isHCL := f.(*formatter.Formatter); isHCL != tt.wantHCL {
+			t.Errorf("formatterFor(%q) = %T, want the hcl formatter: %v", tt.path, f, tt.wantHCL)
+		}
+		if _, isUpper := f.(upperFormatter); isUpper != tt.wantUpper {
+			t.Errorf("formatterFor(%q) = %T, want upperFormatter: %v", tt.path, f, tt.wantUpper)
+		}
+	}
+
+	res := formatContent("notes.upper", []byte("hello"))
+	if string(res.Formatted) != "HELLO" {
+		t.Errorf("formatContent() formatted = %q, want %q", res.Formatted, "HELLO")
+	}
+}
+
+// brokenFormatter is a test Formatter whose output never parses
+type brokenFormatter struct{}
+
+func (brokenFormatter) FormatStats(content []byte) ([]byte, formatter.Stats) {
+	return append(content, "}"...), formatter.Stats{"broken": 1}
+}
+
+// TestVerify verifies -verify catches a formatter producing invalid output
+// and keeps the file as written
+func TestVerify(t *testing.T) {
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origTF := formatters[".tf"]
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		formatters[".tf"] = origTF
+	}()
+
+	cfg = config.NewConfig()
+	formatterInst = formatter.New(cfg)
+	registerFormatter(".tf", func(string) Formatter { return brokenFormatter{} }, always)
+	content := []byte("resource \"example\" \"test\" {\n  foo = bar\n}\n")
+
+	res := formatContent("main.tf", content)
+	if res.Err != nil || !res.Changed {
+		t.Fatalf("formatContent() without -verify = %v, changed %v, want the broken output", res.Err, res.Changed)
+	}
+
+	cfg.Verify = true
+	res = formatContent("main.tf", content)
+	if res.Err == nil {
+		t.Errorf("formatContent() with -verify succeeded, want an error")
+	}
+	if res.Changed || !bytes.Equal(res.Formatted, content) {
+		t.Errorf("formatContent() with -verify = %q, changed %v, want the content unchanged", res.Formatted, res.Changed)
+	}
+}
@@ -0,0 +1,51 @@
+package tffmt
+
+import (
+	"io"
+	"sync"
+
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// syncWriter serializes writes from the worker pool to a single writer
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// resetRun clears the state left behind by a previous Run
+func resetRun() {
+	cache = nil
+	settingsCache = nil
+	nested.Lock()
+	nested.enabled = false
+	nested.Unlock()
+	namingPattern = nil
+	providerAliases.Lock()
+	providerAliases.dirs = map[string]map[string]bool{}
+	providerAliases.Unlock()
+	generatedMarker = nil
+	lockWanted = nil
+	lockSeen = map[string]string{}
+	stats = formatter.Stats{}
+	dirCounts = map[string]*dirCount{}
+	report = &runReport{}
+	blockCounts = nil
+	listGroups = map[string][]string{}
+	checkFormatters.Lock()
+	checkFormatters.derived = map[*formatter.Formatter]*formatter.Formatter{}
+	checkFormatters.Unlock()
+	stdoutHeaders = false
+	jsonOutput.paths, jsonOutput.diffs = nil, nil
+	interrupted.Store(false)
+
+	pending.Lock()
+	pending.writes = nil
+	pending.Unlock()
+}
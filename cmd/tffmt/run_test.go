@@ -0,0 +1,280 @@
+package tffmt
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRun exercises flag parsing, output and exit codes end to end
+func TestRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	unformatted := filepath.Join(tmpDir, "unformatted.tf")
+	formatted := filepath.Join(tmpDir, "formatted.tf")
+	if err := os.WriteFile(unformatted, []byte("resource \"example\" \"test\" {foo = bar}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(formatted, []byte("resource \"example\" \"test\" {\n  foo = bar\n}\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	emptyConfig := filepath.Join(tmpDir, "empty.yml")
+	if err := os.WriteFile(emptyConfig, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	spacingConfig := filepath.Join(tmpDir, "spacing.yml")
+	if err := os.WriteFile(spacingConfig, []byte("block-spacing: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jsonDir := t.TempDir()
+	tfJSON := filepath.Join(jsonDir, "main.tf.json")
+	tofuJSON := filepath.Join(jsonDir, "main.tofu.json")
+	for _, path := range []string{tfJSON, tofuJSON} {
+		if err := os.WriteFile(path, []byte("{\"variable\":{\"name\":{}}}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emptyDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantExit   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "check unformatted file",
+			args:       []string{"-check", unformatted},
+			wantExit:   3,
+			wantStdout: unformatted + "\n",
+		},
+		{
+			name:     "check formatted file",
+			args:     []string{"-check", formatted},
+			wantExit: 0,
+		},
+		{
+			name:       "check directory",
+			args:       []string{"-check", tmpDir},
+			wantExit:   3,
+			wantStdout: unformatted + "\n",
+		},
+		{
+			name:       "stdin",
+			args:       []string{"-stdin"},
+			stdin:      "variable \"name\" {\ntype = string\n}",
+			wantStdout: "variable \"name\" {\n  type = string\n}\n\n",
+		},
+		{
+			name:       "dash for stdin",
+			args:       []string{"-"},
+			stdin:      "variable \"name\" {\ntype = string\n}",
+			wantStdout: "variable \"name\" {\n  type = string\n}\n\n",
+		},
+		{
+			name:     "check stdin",
+			args:     []string{"-check", "-"},
+			stdin:    "variable \"name\" {\ntype = string\n}",
+			wantExit: 3,
+		},
+		{
+			name:     "check formatted stdin",
+			args:     []string{"-check", "-"},
+			stdin:    "variable \"name\" {\n  type = string\n}\n\n",
+			wantExit: 0,
+		},
+		{
+			name:       "diff stdin",
+			args:       []string{"-diff", "-"},
+			stdin:      "variable \"name\" {\ntype = string\n}\n\n",
+			wantStdout: "--- <stdin> (orig)\n+++ <stdin> (fmt)\n@@ -1,5 +1,5 @@\n variable \"name\" {\n-type = string\n+  type = string\n }\n \n \n",
+		},
+		{
+			name:       "stdin with other paths",
+			args:       []string{"-", formatted},
+			wantExit:   2,
+			wantStderr: "can't be combined with other paths",
+		},
+		{
+			name:       "stdout",
+			args:       []string{"-stdout", unformatted},
+			wantStdout: "resource \"example\" \"test\" { foo = bar }\n\n",
+		},
+		{
+			name:       "stdout several files",
+			args:       []string{"-stdout", formatted, unformatted},
+			wantStdout: "==> " + formatted + " <==\nresource \"example\" \"test\" {\n  foo = bar\n}\n\n==> " + unformatted + " <==\nresource \"example\" \"test\" { foo = bar }\n\n",
+		},
+		{
+			name:       "stdout and write",
+			args:       []string{"-stdout", "-write", unformatted},
+			wantExit:   2,
+			wantStderr: "-stdout and -write can't be used together",
+		},
+		{
+			name:       "memory statistics",
+			args:       []string{"-check", "-mem-stats", tmpDir},
+			wantExit:   3,
+			wantStdout: unformatted + "\n",
+			wantStderr: "tffmt: mem: total_alloc=",
+		},
+		{
+			name:       "missing path",
+			args:       []string{"-check", filepath.Join(tmpDir, "missing.tf")},
+			wantExit:   2,
+			wantStderr: "missing.tf: path does not exist",
+		},
+		{
+			name:       "not a terraform file",
+			args:       []string{"-check", emptyConfig},
+			wantExit:   2,
+			wantStderr: "empty.yml: not a .tf file and not a directory",
+		},
+		{
+			name:     "json configuration",
+			args:     []string{"-check", tfJSON},
+			wantExit: 0,
+		},
+		{
+			name:     "tofu json configuration",
+			args:     []string{"-check", "-tofu", tofuJSON},
+			wantExit: 0,
+		},
+		{
+			name:       "tofu json configuration without -tofu",
+			args:       []string{"-check", tofuJSON},
+			wantExit:   2,
+			wantStderr: "main.tofu.json: not a .tf file and not a directory",
+		},
+		{
+			name:     "glob matching other files",
+			args:     []string{"-check", filepath.Join(tmpDir, "*.yml")},
+			wantExit: 0,
+		},
+		{
+			name:     "check empty directory",
+			args:     []string{"-check", emptyDir},
+			wantExit: 0,
+		},
+		{
+			name:       "check empty directory verbosely",
+			args:       []string{"-check", "-verbose", emptyDir},
+			wantExit:   0,
+			wantStderr: emptyDir + ": note: no terraform files found",
+		},
+		{
+			name:       "invalid option value",
+			args:       []string{"-comment-style", "semicolon", tmpDir},
+			wantExit:   1,
+			wantStderr: "invalid -comment-style",
+		},
+		{
+			name:       "block spacing from settings",
+			args:       []string{"-config", spacingConfig, "-stdin"},
+			stdin:      "variable \"a\" {}\n\nvariable \"b\" {}\n",
+			wantStdout: "variable \"a\" {}\nvariable \"b\" {}\n\n",
+		},
+		{
+			name:       "invalid block spacing",
+			args:       []string{"-block-spacing", "3", "-stdin"},
+			stdin:      "variable \"a\" {}\nvariable \"b\" {}\n",
+			wantStdout: "variable \"a\" {}\n\nvariable \"b\" {}\n\n",
+			wantStderr: "Warning: Ignoring invalid block-spacing 3: must be 1 or 2",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"-no-such-flag"},
+			wantExit:   2,
+			wantStderr: "flag provided but not defined",
+		},
+		{
+			name:       "help",
+			args:       []string{"-h"},
+			wantExit:   0,
+			wantStderr: "Usage of tffmt",
+		},
+	}
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			args := append([]string{"-config", emptyConfig}, tt.args...)
+			exit := Run(args, strings.NewReader(tt.stdin), &out, &errOut)
+
+			if exit != tt.wantExit {
+				t.Errorf("Run(%q) = %d, want %d (stderr: %s)", tt.args, exit, tt.wantExit, errOut.String())
+			}
+			if out.String() != tt.wantStdout {
+				t.Errorf("Run(%q) stdout = %q, want %q", tt.args, out.String(), tt.wantStdout)
+			}
+			if !strings.Contains(errOut.String(), tt.wantStderr) {
+				t.Errorf("Run(%q) stderr = %q, want it to contain %q", tt.args, errOut.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	// Printing to stdout never writes the files
+	if content, err := os.ReadFile(unformatted); err != nil || string(content) != "resource \"example\" \"test\" {foo = bar}" {
+		t.Errorf("unformatted.tf = %q, %v, want it left alone", content, err)
+	}
+
+	// JSON configuration is never reformatted
+	for _, path := range []string{tfJSON, tofuJSON} {
+		if content, err := os.ReadFile(path); err != nil || string(content) != "{\"variable\":{\"name\":{}}}" {
+			t.Errorf("%s = %q, %v, want it left alone", path, content, err)
+		}
+	}
+
+	// Nothing but the verbose note is printed for an empty directory
+	var errOut bytes.Buffer
+	if exit := Run([]string{"-config", emptyConfig, "-check", emptyDir}, strings.NewReader(""), &bytes.Buffer{}, &errOut); exit != 0 || errOut.Len() > 0 {
+		t.Errorf("Run(empty dir) = %d, stderr %q, want 0 and nothing", exit, errOut.String())
+	}
+
+	// -check never writes
+	output, err := os.ReadFile(unformatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "resource \"example\" \"test\" {foo = bar}" {
+		t.Errorf("-check modified %s: %q", unformatted, output)
+	}
+}
+
+// TestPathError verifies unreadable paths are told apart from mistyped ones
+func TestPathError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantMsg  string
+		wantCode int
+	}{
+		{"does not exist", &fs.PathError{Op: "stat", Path: "main.tf", Err: fs.ErrNotExist}, "main.tf: path does not exist", 2},
+		{"permission denied", &fs.PathError{Op: "stat", Path: "main.tf", Err: fs.ErrPermission}, "main.tf: permission denied", 1},
+		{"other", &fs.PathError{Op: "stat", Path: "main.tf", Err: errors.New("input/output error")}, "stat main.tf: input/output error", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, code := pathError("main.tf", tt.err)
+			if msg != tt.wantMsg || code != tt.wantCode {
+				t.Errorf("pathError() = %q, %d, want %q, %d", msg, code, tt.wantMsg, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package tffmt
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// saveConfig writes the settings in effect that differ from the defaults
+// to path, under -config-key when set, and returns the exit code. An
+// existing file is only replaced under -force.
+func saveConfig(path string) int {
+	if _, err := os.Stat(path); err == nil && !cfg.Force {
+		fmt.Fprintf(stderr, "tffmt: %s already exists, use -force to overwrite it\n", path)
+		return 1
+	}
+
+	data, err := config.MarshalSettings(config.SettingsFrom(cfg), cfg.ConfigKey)
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "wrote %s\n", path)
+	return 0
+}
@@ -0,0 +1,60 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestSaveConfig verifies -save-config writes settings that load back into
+// the flags given, and only replaces an existing file under -force
+func TestSaveConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	// Save original state and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout, origStderr := stdout, stderr
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout, stderr = origStdout, origStderr
+	}()
+
+	var out, errOut bytes.Buffer
+	if exit := Run([]string{"-sort-inputs", "-save-config"}, strings.NewReader(""), &out, &errOut); exit != 0 {
+		t.Fatalf("exit = %d, want 0 (stderr: %s)", exit, errOut.String())
+	}
+
+	settings, err := config.LoadSettingsFile(".tffmt.yml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := config.NewConfig()
+	config.ApplySettings(loaded, settings, map[string]bool{})
+	if !loaded.SortInputs {
+		t.Errorf("reloaded SortInputs = false, want true")
+	}
+
+	saved, err := os.ReadFile(".tffmt.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exit := Run([]string{"-sort-vars", "-save-config"}, strings.NewReader(""), &out, &errOut); exit != 1 {
+		t.Errorf("exit without -force = %d, want 1", exit)
+	}
+	if content, _ := os.ReadFile(".tffmt.yml"); !bytes.Equal(content, saved) {
+		t.Errorf(".tffmt.yml was overwritten without -force:\n%s", content)
+	}
+
+	if exit := Run([]string{"-sort-vars", "-save-config", "-force"}, strings.NewReader(""), &out, &errOut); exit != 0 {
+		t.Errorf("exit with -force = %d, want 0 (stderr: %s)", exit, errOut.String())
+	}
+	if content, _ := os.ReadFile(".tffmt.yml"); !strings.Contains(string(content), "sort-vars: true") {
+		t.Errorf(".tffmt.yml after -force = %q, want the new settings", content)
+	}
+}
@@ -0,0 +1,110 @@
+package tffmt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// changedSinceCommit returns the terraform files under root that were
+// added or modified since ref, counting commits, staged changes and
+// untracked files in the working tree. It reads the repository with go-git,
+// so it works without the git binary.
+func changedSinceCommit(root, ref string) ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening git repository: %w", err)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+
+	changed := map[string]bool{}
+
+	// Files changed between ref and HEAD
+	baseTree, err := commitTree(repo, *hash)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := commitTree(repo, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.To.Name != "" {
+			changed[change.To.Name] = true
+		}
+	}
+
+	// Files changed between HEAD and the working tree
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+	for path, s := range status {
+		if s.Worktree != git.Deleted && s.Staging != git.Deleted {
+			changed[path] = true
+		}
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	repoRoot, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(absRoot); err == nil {
+		absRoot = resolved
+	}
+
+	var paths []string
+	for name := range changed {
+		rel, err := filepath.Rel(absRoot, filepath.Join(repoRoot, filepath.FromSlash(name)))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !cfg.Recursive && strings.ContainsRune(rel, filepath.Separator) {
+			continue
+		}
+		path := filepath.Join(root, rel)
+		if !isTerraformFile(path) {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// commitTree returns the tree of the commit with the given hash
+func commitTree(repo *git.Repository, hash plumbing.Hash) (*object.Tree, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
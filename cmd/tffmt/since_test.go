@@ -0,0 +1,69 @@
+package tffmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestChangedSinceCommit verifies only the .tf files modified or added since
+// a commit are selected, using a go-git repository
+func TestChangedSinceCommit(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo, err := git.PlainInit(tmpDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.tf", "resource \"example\" \"test\" {}\n")
+	write("vars.tf", "variable \"name\" {}\n")
+	write("README.md", "docs\n")
+	if err := worktree.AddGlob("."); err != nil {
+		t.Fatal(err)
+	}
+	_, err = worktree.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("main.tf", "resource \"example\" \"test\" {foo = bar}\n")
+	write("README.md", "more docs\n")
+	write("new.tf", "output \"x\" {}\n")
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg = config.NewConfig()
+
+	paths, err := changedSinceCommit(tmpDir, "HEAD")
+	if err != nil {
+		t.Fatalf("changedSinceCommit() error = %v", err)
+	}
+
+	expected := []string{filepath.Join(tmpDir, "main.tf"), filepath.Join(tmpDir, "new.tf")}
+	if len(paths) != len(expected) {
+		t.Fatalf("changedSinceCommit() = %q, want %q", paths, expected)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("changedSinceCommit()[%d] = %q, want %q", i, paths[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package tffmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+var (
+	stats     = formatter.Stats{}
+	dirCounts = map[string]*dirCount{}
+	report    = &runReport{}
+)
+
+// runReport collects the outcome of every file for the -report summary
+type runReport struct {
+	files   int
+	changed []string
+	errors  []string
+
+	// linesAdded and linesRemoved total the line changes for -count-lines
+	linesAdded, linesRemoved int
+}
+
+// dirCount tallies the files processed in a single directory
+type dirCount struct {
+	files       int
+	unformatted int
+}
+
+// recordSummary adds a file result to the end-of-run summaries
+func recordSummary(res FileResult) {
+	report.files++
+	if res.Err != nil {
+		report.errors = append(report.errors, fmt.Sprintf("%s: %v", res.Path, res.Err))
+		return
+	}
+	if res.Changed {
+		report.changed = append(report.changed, res.Path)
+		if cfg.CountLines {
+			added, removed := lineDelta(res.Orig, res.Formatted)
+			report.linesAdded += added
+			report.linesRemoved += removed
+		}
+	}
+
+	stats.Add(res.Stats)
+	if cfg.CountAttributes {
+		recordAttributeCounts(res.Path, res.Orig)
+	}
+
+	dir := filepath.Dir(res.Path)
+	count, ok := dirCounts[dir]
+	if !ok {
+		count = &dirCount{}
+		dirCounts[dir] = count
+	}
+	count.files++
+	if res.Changed {
+		count.unformatted++
+	}
+}
+
+// printSummaries prints the end-of-run summaries that were requested
+func printSummaries() {
+	if cfg.JSON {
+		printJSONOutput()
+	}
+	if cfg.GroupList {
+		printListGroups()
+	}
+	if cfg.Count {
+		for _, line := range countLines() {
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	if cfg.CountLines {
+		fmt.Fprintln(stdout, lineDeltaLine())
+	}
+	if cfg.CountAttributes {
+		for _, line := range attributeCountLines(cfg.Top) {
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	if cfg.StatsJSON {
+		printStatsJSON()
+	}
+	if cfg.Report != "" {
+		if err := writeReport(cfg.Report); err != nil {
+			fmt.Fprintln(stderr, "tffmt: writing report:", err)
+		}
+	}
+	if cfg.MemStats {
+		fmt.Fprintln(stderr, memStatsLine())
+	}
+}
+
+// writeReport writes a human-readable summary of the run to path
+func writeReport(path string) error {
+	var b strings.Builder
+	b.WriteString("tffmt report\n")
+	b.WriteString("============\n\n")
+	fmt.Fprintf(&b, "Files processed:   %d\n", report.files)
+	fmt.Fprintf(&b, "Files reformatted: %d\n", len(report.changed))
+	fmt.Fprintf(&b, "Files with errors: %d\n", len(report.errors))
+
+	b.WriteString("\nReformatted files:\n")
+	writeReportList(&b, report.changed)
+
+	b.WriteString("\nErrors:\n")
+	writeReportList(&b, report.errors)
+
+	b.WriteString("\nRules fired:\n")
+	rules := make([]string, 0, len(stats))
+	for rule := range stats {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	lines := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, fmt.Sprintf("%s: %d", rule, stats[rule]))
+	}
+	writeReportList(&b, lines)
+
+	return os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// writeReportList writes an indented list, or "(none)" when it is empty
+func writeReportList(b *strings.Builder, items []string) {
+	if len(items) == 0 {
+		b.WriteString("  (none)\n")
+		return
+	}
+	for _, item := range items {
+		fmt.Fprintf(b, "  %s\n", item)
+	}
+}
+
+// countLines returns the per-directory file counts, sorted by directory
+func countLines() []string {
+	dirs := make([]string, 0, len(dirCounts))
+	for dir := range dirCounts {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	lines := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		count := dirCounts[dir]
+		lines = append(lines, fmt.Sprintf("%s: %d files, %d unformatted", dir, count.files, count.unformatted))
+	}
+	return lines
+}
+
+// lineDeltaLine returns the lines formatting adds and removes across all
+// files, and the number of files it changes
+func lineDeltaLine() string {
+	return fmt.Sprintf("%d lines added, %d lines removed in %d files", report.linesAdded, report.linesRemoved, len(report.changed))
+}
+
+// printStatsJSON prints the formatting rule counts aggregated over all files
+func printStatsJSON() {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		fmt.Fprintln(stderr, "tffmt:", err)
+		return
+	}
+	fmt.Fprintln(stdout, string(data))
+}
@@ -0,0 +1,182 @@
+package tffmt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestCountLines verifies files are tallied per directory in recursive mode
+func TestCountLines(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tffmt-count-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	formatted := "resource \"example\" \"test\" {\n  foo = bar\n}\n\n"
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	files := map[string]string{
+		"main.tf":                unformatted,
+		"modules/vpc/main.tf":    formatted,
+		"modules/vpc/outputs.tf": unformatted,
+		"modules/vpc/vars.tf":    unformatted,
+		"modules/vpc/README.md":  "not terraform",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origCounts := dirCounts
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		dirCounts = origCounts
+	}()
+
+	cfg = config.NewConfig()
+	cfg.Write = false
+	cfg.List = false
+	cfg.Recursive = true
+	cfg.Count = true
+	formatterInst = formatter.New(cfg)
+	dirCounts = map[string]*dirCount{}
+
+	exit := 0
+	if err := walkDir(tmpDir, &exit); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		tmpDir + ": 1 files, 1 unformatted",
+		filepath.Join(tmpDir, "modules", "vpc") + ": 3 files, 2 unformatted",
+	}
+	lines := countLines()
+	if len(lines) != len(expected) {
+		t.Fatalf("countLines() = %v, want %v", lines, expected)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("countLines()[%d] = %q, want %q", i, lines[i], expected[i])
+		}
+	}
+}
+
+// TestCountLineDelta verifies -count-lines totals the lines formatting
+// adds and removes over a tree
+func TestCountLineDelta(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.tf":             "resource \"example\" \"test\" {foo = bar}",
+		"modules/vpc/main.tf": "resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+		"modules/vpc/vars.tf": "variable \"a\" {\ntype = string\n}\n\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origReport := report
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		report = origReport
+	}()
+
+	cfg = config.NewConfig()
+	cfg.Write = false
+	cfg.List = false
+	cfg.Check = true
+	cfg.Recursive = true
+	cfg.CountLines = true
+	formatterInst = formatter.New(cfg)
+	report = &runReport{}
+
+	exit := 0
+	if err := walkDir(tmpDir, &exit); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "3 lines added, 2 lines removed in 2 files"
+	if line := lineDeltaLine(); line != expected {
+		t.Errorf("lineDeltaLine() = %q, want %q", line, expected)
+	}
+}
+
+// TestWriteReport verifies the report lists totals, reformatted files,
+// errors and fired rules
+func TestWriteReport(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tffmt-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	origReport := report
+	origStats := stats
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		report = origReport
+		stats = origStats
+	}()
+
+	cfg = config.NewConfig()
+	cfg.List = false
+	formatterInst = formatter.New(cfg)
+	report = &runReport{}
+	stats = formatter.Stats{}
+
+	exit := 0
+	_ = handleResult(formatContent("clean.tf", []byte("resource \"a\" \"b\" {\n  foo = bar\n}\n\n")), &exit)
+	_ = handleResult(formatContent("messy.tf", []byte("resource \"a\" \"b\" {\nfoo = bar\n}")), &exit)
+	_ = handleResult(FileResult{Path: "broken.tf", Err: os.ErrPermission}, &exit)
+
+	reportPath := filepath.Join(tmpDir, "report.txt")
+	if err := writeReport(reportPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"Files processed:   3\n",
+		"Files reformatted: 1\n",
+		"Files with errors: 1\n",
+		"Reformatted files:\n  messy.tf\n",
+		"Errors:\n  broken.tf: permission denied\n",
+		"Rules fired:\n",
+		"  hcl_format: 1\n",
+		"  trailing_newlines: 1\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report does not contain %q:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+//go:build unix
+
+package tffmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestSymlinkWrite verifies symlinked files are written through by default
+// and left alone with -no-follow-symlink-write
+func TestSymlinkWrite(t *testing.T) {
+	tests := []struct {
+		name          string
+		noFollow      bool
+		wantFormatted bool
+	}{
+		{"write through", false, true},
+		{"skip symlink", true, false},
+	}
+
+	content := "resource \"example\" \"test\" {foo = bar}"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			target := filepath.Join(tmpDir, "shared.tf.txt")
+			link := filepath.Join(tmpDir, "main.tf")
+			if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(target, link); err != nil {
+				t.Fatal(err)
+			}
+
+			// Save original config and restore it afterwards
+			origCfg := cfg
+			origFormatter := formatterInst
+			defer func() {
+				cfg = origCfg
+				formatterInst = origFormatter
+			}()
+
+			cfg = config.NewConfig()
+			cfg.List = false
+			cfg.NoFollowSymlinks = tt.noFollow
+			formatterInst = formatter.New(cfg)
+
+			res := processFile(link)
+			if res.Err != nil {
+				t.Fatalf("processFile() error = %v", res.Err)
+			}
+			if res.Skipped != tt.noFollow {
+				t.Errorf("processFile() skipped = %v, want %v", res.Skipped, tt.noFollow)
+			}
+
+			output, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formatted := string(output) != content; formatted != tt.wantFormatted {
+				t.Errorf("symlink target formatted = %v, want %v", formatted, tt.wantFormatted)
+			}
+
+			info, err := os.Lstat(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("%s is no longer a symlink", link)
+			}
+		})
+	}
+}
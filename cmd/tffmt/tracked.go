@@ -0,0 +1,54 @@
+package tffmt
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// filterTracked returns the paths, found under the directory root, that
+// are tracked by the git repository holding root. Like -since-commit it
+// reads the repository with go-git, so it works without the git binary.
+func filterTracked(root string, paths []string) ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening git repository: %w", err)
+	}
+	index, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	repoRoot, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[string]bool, len(index.Entries))
+	for _, entry := range index.Entries {
+		tracked[filepath.Join(repoRoot, filepath.FromSlash(entry.Name))] = true
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(absRoot); err == nil {
+		absRoot = resolved
+	}
+
+	var kept []string
+	for _, path := range paths {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+		if tracked[filepath.Join(absRoot, rel)] {
+			kept = append(kept, path)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,75 @@
+package tffmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestOnlyTracked verifies -only-tracked formats tracked files and leaves
+// untracked ones alone
+func TestOnlyTracked(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo, err := git.PlainInit(tmpDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unformatted := "resource \"example\" \"test\" {\nfoo = bar\n}\n"
+	for _, name := range []string{"main.tf", "modules/vpc/main.tf", "scratch.tf"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(unformatted), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"main.tf", "modules/vpc/main.tf"} {
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Save original config and restore it afterwards
+	origCfg := cfg
+	origFormatter := formatterInst
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+	}()
+
+	cfg = config.NewConfig()
+	cfg.List = false
+	cfg.Recursive = true
+	cfg.OnlyTracked = true
+	formatterInst = formatter.New(cfg)
+
+	exit := 0
+	if err := processDir(tmpDir, &exit); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"main.tf":             "resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+		"modules/vpc/main.tf": "resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+		"scratch.tf":          unformatted,
+	}
+	for name, want := range expected {
+		content, err := os.ReadFile(filepath.Join(tmpDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Errorf("%s = %q, want %q", name, content, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package tffmt
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// watchInterval is how often -watch looks for changed files
+var watchInterval = 250 * time.Millisecond
+
+// watch formats the terraform files under paths whenever they change,
+// until stop is closed. Changes arriving within -debounce of each other,
+// such as when switching branches, are formatted as a single batch.
+func watch(paths []string, stop <-chan struct{}) int {
+	events := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		debounce(events, cfg.Debounce, formatBatch)
+	}()
+
+	seen := watchSnapshot(paths)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			close(events)
+			<-done
+			return 0
+		case <-ticker.C:
+			current := watchSnapshot(paths)
+			for path, modTime := range current {
+				if !seen[path].Equal(modTime) {
+					events <- path
+				}
+			}
+			seen = current
+		}
+	}
+}
+
+// watchSnapshot returns the modification times of the terraform files
+// under paths
+func watchSnapshot(paths []string) map[string]time.Time {
+	files := map[string]time.Time{}
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if !cfg.Recursive && path != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if path != root && !isTerraformFile(path) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				files[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// debounce collects the paths received on events until none has arrived
+// for window, then calls flush once with the batch, sorted and without
+// duplicates. It returns when events is closed, flushing what is left.
+func debounce(events <-chan string, window time.Duration, flush func([]string)) {
+	batch := map[string]bool{}
+	var timer <-chan time.Time
+	emit := func() {
+		if len(batch) == 0 {
+			return
+		}
+		paths := make([]string, 0, len(batch))
+		for path := range batch {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		batch = map[string]bool{}
+		flush(paths)
+	}
+
+	for {
+		select {
+		case path, ok := <-events:
+			if !ok {
+				emit()
+				return
+			}
+			batch[path] = true
+			timer = time.After(window)
+		case <-timer:
+			timer = nil
+			emit()
+		}
+	}
+}
+
+// formatBatch formats a batch of changed files and prints a single summary
+// line instead of one line per file
+func formatBatch(paths []string) {
+	start := time.Now()
+	reformatted := 0
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			// Removed since the change was seen
+			continue
+		}
+		res := processFile(path)
+		if res.Err != nil {
+			fmt.Fprintln(stderr, "tffmt:", res.Err)
+			continue
+		}
+		for _, issue := range res.Issues {
+			fmt.Fprintln(stderr, issue)
+		}
+		if res.Changed {
+			reformatted++
+		}
+	}
+	if reformatted > 0 {
+		fmt.Fprintf(stdout, "reformatted %d files in %s\n", reformatted, time.Since(start).Round(time.Millisecond))
+	}
+}
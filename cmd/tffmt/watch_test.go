@@ -0,0 +1,83 @@
+package tffmt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/krewenki/tffmt/pkg/config"
+	"github.com/krewenki/tffmt/pkg/formatter"
+)
+
+// TestDebounce verifies a burst of change events is flushed as one batch
+func TestDebounce(t *testing.T) {
+	events := make(chan string)
+	var batches [][]string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		debounce(events, 50*time.Millisecond, func(paths []string) {
+			batches = append(batches, paths)
+		})
+	}()
+
+	for _, path := range []string{"b.tf", "a.tf", "c.tf", "a.tf", "b.tf"} {
+		events <- path
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(150 * time.Millisecond)
+	close(events)
+	<-done
+
+	want := [][]string{{"a.tf", "b.tf", "c.tf"}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+}
+
+// TestFormatBatch verifies a batch is formatted with a single summary line
+func TestFormatBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	unformatted := "resource \"example\" \"test\" {foo = bar}"
+	var paths []string
+	for _, name := range []string{"a.tf", "b.tf", "c.tf"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(unformatted), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	origCfg := cfg
+	origFormatter := formatterInst
+	origStdout := stdout
+	defer func() {
+		cfg = origCfg
+		formatterInst = origFormatter
+		stdout = origStdout
+	}()
+
+	cfg = config.NewConfig()
+	formatterInst = formatter.New(cfg)
+	var out bytes.Buffer
+	stdout = &out
+
+	formatBatch(append(paths, filepath.Join(tmpDir, "removed.tf")))
+
+	if !regexp.MustCompile(`^reformatted 3 files in \d+(\.\d+)?[µnm]?s\n$`).MatchString(out.String()) {
+		t.Errorf("output = %q, want a single summary line", out.String())
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) == unformatted {
+			t.Errorf("%s was not formatted", path)
+		}
+	}
+}
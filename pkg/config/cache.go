@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SettingsCache finds and loads the settings that apply to directories,
+// looking for the settings file of each directory and loading each file
+// at most once for the lifetime of the cache. It is safe for concurrent use.
+type SettingsCache struct {
+	key string
+
+	// searchPaths are consulted before the usual locations, and
+	// searchFile is the first settings file found in them once searched
+	searchPaths []string
+	searched    bool
+	searchFile  string
+
+	mu    sync.Mutex
+	files map[string]string        // directory => settings file, "" for none
+	loads map[string]loadedSetting // settings file => its settings
+
+	// load reads a settings file, LoadSettingsFile outside of tests
+	load func(path, key string) (Settings, error)
+}
+
+// loadedSetting is the outcome of loading a settings file
+type loadedSetting struct {
+	settings Settings
+	err      error
+}
+
+// NewSettingsCache creates an empty cache whose settings are read from the
+// table at key of each file, as with LoadSettingsFile. Settings files in
+// searchPaths, each a settings file or a directory holding a .tffmt.yml,
+// win over those in the usual locations, the first found applying to
+// every directory.
+func NewSettingsCache(key string, searchPaths ...string) *SettingsCache {
+	return &SettingsCache{
+		key:         key,
+		searchPaths: searchPaths,
+		files:       map[string]string{},
+		loads:       map[string]loadedSetting{},
+		load:        LoadSettingsFile,
+	}
+}
+
+// ConfigFileFor returns the settings file for dir: the first one found in
+// the search paths, or else .tffmt.yml in dir or the closest parent holding
+// one, or else ~/.config/tffmt/tffmt.yml. It returns an empty string if
+// none exists.
+func (c *SettingsCache) ConfigFileFor(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.searched {
+		c.searchFile = searchPathFile(c.searchPaths)
+		c.searched = true
+	}
+	if c.searchFile != "" {
+		return c.searchFile
+	}
+	return c.configFileFor(abs)
+}
+
+// searchPathFile returns the first settings file found in paths, each a
+// settings file or a directory that may hold a .tffmt.yml
+func searchPathFile(paths []string) string {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			path = filepath.Join(path, ".tffmt.yml")
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+		}
+		return path
+	}
+	return ""
+}
+
+// configFileFor looks up the settings file for the absolute path dir,
+// remembering the answer for dir and each of its parents
+func (c *SettingsCache) configFileFor(dir string) string {
+	if path, ok := c.files[dir]; ok {
+		return path
+	}
+
+	path := filepath.Join(dir, ".tffmt.yml")
+	if _, err := os.Stat(path); err != nil {
+		if parent := filepath.Dir(dir); parent != dir {
+			path = c.configFileFor(parent)
+		} else {
+			path = homeConfigFile()
+		}
+	}
+	c.files[dir] = path
+	return path
+}
+
+// homeConfigFile returns ~/.config/tffmt/tffmt.yml if it exists
+func homeConfigFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(homeDir, ".config", "tffmt", "tffmt.yml")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// SettingsFor returns the settings that apply to dir, or empty settings
+// when it has no settings file
+func (c *SettingsCache) SettingsFor(dir string) (Settings, error) {
+	path := c.ConfigFileFor(dir)
+	if path == "" {
+		return Settings{}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	loaded, ok := c.loads[path]
+	if !ok {
+		loaded.settings, loaded.err = c.load(path, c.key)
+		c.loads[path] = loaded
+	}
+	return loaded.settings, loaded.err
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSettingsCache verifies each directory's settings file is found once
+// and each settings file is loaded once, however many files ask for it
+func TestSettingsCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	modules := filepath.Join(tmpDir, "modules")
+	dirs := []string{filepath.Join(modules, "vpc"), filepath.Join(modules, "db")}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(tmpDir, ".tffmt.yml")
+	if err := os.WriteFile(configPath, []byte("sort-vars: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewSettingsCache("")
+	loads := map[string]int{}
+	cache.load = func(path, key string) (Settings, error) {
+		loads[path]++
+		return LoadSettingsFile(path, key)
+	}
+
+	for i := 0; i < 1000; i++ {
+		for _, dir := range dirs {
+			settings, err := cache.SettingsFor(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settings.SortVars == nil || !*settings.SortVars {
+				t.Fatalf("SettingsFor(%s).SortVars = %v, want true", dir, settings.SortVars)
+			}
+		}
+	}
+
+	if len(loads) != 1 || loads[configPath] != 1 {
+		t.Errorf("loads = %v, want %s loaded once", loads, configPath)
+	}
+	for _, dir := range append(dirs, modules, tmpDir) {
+		if path, ok := cache.files[dir]; !ok || path != configPath {
+			t.Errorf("cached settings file for %s = %q, %v, want %s", dir, path, ok, configPath)
+		}
+	}
+}
+
+// TestSettingsCacheSearchPaths verifies the first settings file found in
+// the search paths wins over the file of the directory itself
+func TestSettingsCacheSearchPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	project := filepath.Join(tmpDir, "project")
+	custom := filepath.Join(tmpDir, "custom")
+	for _, dir := range []string{project, custom} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	projectFile := filepath.Join(project, ".tffmt.yml")
+	customFile := filepath.Join(custom, ".tffmt.yml")
+	namedFile := filepath.Join(tmpDir, "ci.yml")
+	for _, path := range []string{projectFile, customFile, namedFile} {
+		if err := os.WriteFile(path, []byte("sort-vars: true\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := filepath.Join(tmpDir, "missing")
+
+	tests := []struct {
+		name        string
+		searchPaths []string
+		expected    string
+	}{
+		{"default locations", nil, projectFile},
+		{"directory", []string{custom}, customFile},
+		{"settings file", []string{namedFile, custom}, namedFile},
+		{"missing entries are skipped", []string{missing, tmpDir, custom}, customFile},
+		{"nothing found", []string{missing}, projectFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewSettingsCache("", tt.searchPaths...)
+			if got := cache.ConfigFileFor(project); got != tt.expected {
+				t.Errorf("ConfigFileFor() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
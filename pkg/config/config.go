@@ -4,32 +4,192 @@ package config
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
-// Settings holds the configuration options for the formatting tool
+// Settings holds the configuration options for the formatting tool. Keys
+// are hyphenated like the flags, but settings files may write them with
+// underscores instead, as in block_spacing.
 type Settings struct {
-	Write      *bool `yaml:"write"`
-	Check      *bool `yaml:"check"`
-	List       *bool `yaml:"list"`
-	Diff       *bool `yaml:"diff"`
-	Recursive  *bool `yaml:"recursive"`
-	SortInputs *bool `yaml:"sort-inputs"`
-	SortVars   *bool `yaml:"sort-vars"`
+	Write       *bool `yaml:"write"`
+	Check       *bool `yaml:"check"`
+	List        *bool `yaml:"list"`
+	Diff        *bool `yaml:"diff"`
+	Recursive   *bool `yaml:"recursive"`
+	SortInputs  *bool `yaml:"sort-inputs"`
+	SortVars    *bool `yaml:"sort-vars"`
+	SortData    *bool `yaml:"sort-data"`
+	SortOutputs *bool `yaml:"sort-outputs"`
+
+	FixHeredocIndent *bool    `yaml:"fix-heredoc-indent"`
+	CacheFile        *string  `yaml:"cache"`
+	Tofu             *bool    `yaml:"tofu"`
+	CheckNaming      *bool    `yaml:"check-naming"`
+	NamingPattern    *string  `yaml:"naming-pattern"`
+	CommentStyle     *string  `yaml:"comment-style"`
+	PreserveAttrs    []string `yaml:"preserve-attrs"`
+	AlignScope       *string  `yaml:"align-scope"`
+	CheckBackend     *bool    `yaml:"check-backend"`
+
+	NoSortCommentBlocks *bool `yaml:"no-sort-comment-blocks"`
+	CanonicalDynamic    *bool `yaml:"canonical-dynamic"`
+	FailFast            *bool `yaml:"fail-fast"`
+	ListUnchanged       *bool `yaml:"list-unchanged"`
+	NoFollowSymlinks    *bool `yaml:"no-follow-symlink-write"`
+
+	NormalizeEOLInStrings        *bool    `yaml:"normalize-eol-within-strings"`
+	NoReorderTypes               []string `yaml:"no-reorder-types"`
+	MergeLocals                  *bool    `yaml:"merge-locals"`
+	SortLocals                   *bool    `yaml:"sort-locals"`
+	Modernize                    *bool    `yaml:"modernize"`
+	DiffFormat                   *string  `yaml:"diff-format"`
+	SkipGenerated                *bool    `yaml:"skip-generated"`
+	GeneratedMarker              *string  `yaml:"generated-marker"`
+	GroupList                    *bool    `yaml:"group-list"`
+	NoColor                      *bool    `yaml:"no-color"`
+	CheckProviderRefs            *bool    `yaml:"check-provider-refs"`
+	NormalizeBoolAttrs           []string `yaml:"normalize-bool-attrs"`
+	VarsFirst                    []string `yaml:"vars-first"`
+	AttributeOrder               []string `yaml:"attribute-order"`
+	IgnoreSortInCheck            *bool    `yaml:"ignore-sort-in-check"`
+	WrapCalls                    *int     `yaml:"wrap-calls"`
+	SortCaseInsensitive          *bool    `yaml:"sort-case-insensitive"`
+	Include                      []string `yaml:"include"`
+	Verify                       *bool    `yaml:"verify"`
+	DiffByBlock                  *bool    `yaml:"diff-by-block"`
+	Explain                      *bool    `yaml:"explain"`
+	SkipUnsafePasses             *bool    `yaml:"skip-unsafe-passes"`
+	NormalizeListSpacing         *bool    `yaml:"normalize-list-spacing"`
+	IgnoreTrailingNewlines       *bool    `yaml:"ignore-trailing-newlines"`
+	MaxFileSize                  *int64   `yaml:"max-file-size"`
+	Oversized                    *string  `yaml:"oversized"`
+	NormalizeEscapes             *bool    `yaml:"normalize-escapes"`
+	Touch                        *bool    `yaml:"touch"`
+	Indent                       *int     `yaml:"indent"`
+	BlockSpacing                 *int     `yaml:"block-spacing"`
+	NormalizeProviderVersions    *bool    `yaml:"normalize-provider-versions"`
+	IgnoreCommentChanges         *bool    `yaml:"ignore-comment-changes"`
+	FoldDescriptions             *int     `yaml:"fold-descriptions"`
+	VerifyNoop                   *bool    `yaml:"verify-noop"`
+	CheckEmptyDefaults           *bool    `yaml:"check-empty-defaults"`
+	AlignTrailingComments        *bool    `yaml:"align-trailing-comments"`
+	CheckAlignment               *bool    `yaml:"check-alignment"`
+	PreserveInterleaving         *bool    `yaml:"preserve-interleaving"`
+	CountLines                   *bool    `yaml:"count-lines"`
+	OnlyTracked                  *bool    `yaml:"only-tracked"`
+	CanonicalLifecycle           *bool    `yaml:"canonical-lifecycle"`
+	FirstDiffLine                *bool    `yaml:"first-diff-line"`
+	PreserveBlankLinesInComments *bool    `yaml:"preserve-blank-lines-in-comments"`
 }
 
 // Config holds all configuration and flag values
 type Config struct {
-	Write      bool
-	Check      bool
-	List       bool
-	Diff       bool
-	Recursive  bool
-	Test       bool
-	SortInputs bool
-	SortVars   bool
+	Write       bool
+	Check       bool
+	List        bool
+	Diff        bool
+	Recursive   bool
+	Test        bool
+	SortInputs  bool
+	SortVars    bool
+	SortData    bool
+	SortOutputs bool
+
+	FixHeredocIndent bool
+	CacheFile        string
+	Tofu             bool
+	DumpAST          string
+	ConfigFile       string
+	ConfigKey        string
+	CheckNaming      bool
+	NamingPattern    string
+	ModifiedSince    time.Duration
+	CommentStyle     string
+	StatsJSON        bool
+	PreserveAttrs    []string
+	Count            bool
+	AlignScope       string
+	CheckBackend     bool
+	Report           string
+	Stdin            bool
+	Fragment         bool
+	CountAttributes  bool
+	Verbose          bool
+	Parallel         int
+	SinceCommit      string
+	WriteLock        string
+	VerifyLock       string
+	Atomic           bool
+	Watch            bool
+	Debounce         time.Duration
+	LabelMatch       string
+	MemStats         bool
+	Learn            string
+	Top              int
+	LSP              bool
+	SaveConfig       bool
+	Force            bool
+
+	NoSortCommentBlocks bool
+	CanonicalDynamic    bool
+	FailFast            bool
+	ListUnchanged       bool
+	NoFollowSymlinks    bool
+
+	NormalizeEOLInStrings        bool
+	NoReorderTypes               []string
+	MergeLocals                  bool
+	SortLocals                   bool
+	Modernize                    bool
+	DiffFormat                   string
+	SkipGenerated                bool
+	GeneratedMarker              string
+	GroupList                    bool
+	NoColor                      bool
+	CheckProviderRefs            bool
+	NormalizeBoolAttrs           []string
+	VarsFirst                    []string
+	AttributeOrder               []string
+	IgnoreSortInCheck            bool
+	WrapCalls                    int
+	SortCaseInsensitive          bool
+	Include                      []string
+	Verify                       bool
+	DiffByBlock                  bool
+	Explain                      bool
+	SkipUnsafePasses             bool
+	NormalizeListSpacing         bool
+	IgnoreTrailingNewlines       bool
+	MaxFileSize                  int64
+	Oversized                    string
+	NormalizeEscapes             bool
+	Touch                        bool
+	Indent                       int
+	BlockSpacing                 int
+	Progress                     bool
+	Stdout                       bool
+	StdinMultiple                bool
+	StdinErrors                  string
+	ListOptions                  bool
+	JSON                         bool
+	NormalizeProviderVersions    bool
+	IgnoreCommentChanges         bool
+	FoldDescriptions             int
+	VerifyNoop                   bool
+	ConfigSearchPaths            []string
+	CheckEmptyDefaults           bool
+	AlignTrailingComments        bool
+	CheckAlignment               bool
+	PreserveInterleaving         bool
+	CountLines                   bool
+	OnlyTracked                  bool
+	CanonicalLifecycle           bool
+	FirstDiffLine                bool
+	PreserveBlankLinesInComments bool
 }
 
 // NewConfig creates a new Config with default values
@@ -43,67 +203,81 @@ func NewConfig() *Config {
 		Test:       false,
 		SortInputs: false,
 		SortVars:   false,
+
+		FixHeredocIndent: false,
+		CacheFile:        "",
+		Tofu:             false,
+		CheckNaming:      false,
+		NamingPattern:    `^[a-z][a-z0-9_]*$`,
+		CommentStyle:     "hash",
+		AlignScope:       "group",
+		Debounce:         200 * time.Millisecond,
+
+		DiffFormat:      "unified",
+		GeneratedMarker: `^(#|//) Code generated .* DO NOT EDIT\.$`,
+		Oversized:       "skip",
+		Indent:          2,
+		BlockSpacing:    2,
+		StdinErrors:     "marker",
 	}
 }
 
+// Clone returns a copy of c that shares nothing with it, so the copy can
+// have settings applied while other goroutines keep using c
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.PreserveAttrs = slices.Clone(c.PreserveAttrs)
+	clone.NoReorderTypes = slices.Clone(c.NoReorderTypes)
+	clone.NormalizeBoolAttrs = slices.Clone(c.NormalizeBoolAttrs)
+	clone.VarsFirst = slices.Clone(c.VarsFirst)
+	clone.AttributeOrder = slices.Clone(c.AttributeOrder)
+	clone.Include = slices.Clone(c.Include)
+	clone.ConfigSearchPaths = slices.Clone(c.ConfigSearchPaths)
+	return &clone
+}
+
 // FindConfigFile looks for a settings file in the following locations:
 // 1. .tffmt.yml in the current directory
 // 2. .tffmt.yml in any parent directory
 // 3. ~/.config/tffmt/tffmt.yml
 // Returns the path to the first file found, or an empty string if none exists.
+// The search is the one a SettingsCache makes for the current directory.
 func FindConfigFile() string {
-	// 1. Check current directory
-	if _, err := os.Stat(".tffmt.yml"); err == nil {
-		return ".tffmt.yml"
-	}
-
-	// 2. Check parent directories
-	dir, err := os.Getwd()
-	if err == nil {
-		for {
-			parentDir := filepath.Dir(dir)
-			if parentDir == dir {
-				// We've reached the root directory
-				break
-			}
-
-			path := filepath.Join(parentDir, ".tffmt.yml")
-			if _, err := os.Stat(path); err == nil {
-				return path
-			}
-
-			dir = parentDir
-		}
-	}
-
-	// 3. Check user's home directory
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		path := filepath.Join(homeDir, ".config", "tffmt", "tffmt.yml")
-		if _, err := os.Stat(path); err == nil {
-			return path
-		}
-	}
-
-	// No settings file found
-	return ""
+	return NewSettingsCache("").ConfigFileFor(".")
 }
 
 // LoadSettings attempts to load settings from a config file
 func LoadSettings() (Settings, error) {
-	settings := Settings{}
-
 	configPath := FindConfigFile()
 	if configPath == "" {
 		// No config file found, return defaults
-		return settings, nil
+		return Settings{}, nil
 	}
+	return LoadSettingsFile(configPath, "")
+}
+
+// LoadSettingsFile loads settings from the file at configPath. When key is
+// not empty the settings are read from the table at that dot-separated key
+// path, so they can live in a file shared with other tools.
+func LoadSettingsFile(configPath, key string) (Settings, error) {
+	settings := Settings{}
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return settings, err
 	}
 
+	if key != "" {
+		data, err = subTable(data, key)
+		if err != nil {
+			return settings, fmt.Errorf("error parsing %s: %w", configPath, err)
+		}
+	}
+
+	data, err = hyphenateKeys(data)
+	if err != nil {
+		return settings, fmt.Errorf("error parsing %s: %w", configPath, err)
+	}
 	err = yaml.Unmarshal(data, &settings)
 	if err != nil {
 		return settings, fmt.Errorf("error parsing %s: %w", configPath, err)
@@ -112,6 +286,46 @@ func LoadSettings() (Settings, error) {
 	return settings, nil
 }
 
+// hyphenateKeys rewrites the underscores in the top-level keys of the
+// settings in data as hyphens
+func hyphenateKeys(data []byte) ([]byte, error) {
+	var table yaml.MapSlice
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return nil, err
+	}
+	if len(table) == 0 {
+		return data, nil
+	}
+	for i, item := range table {
+		if key, ok := item.Key.(string); ok {
+			table[i].Key = strings.ReplaceAll(key, "_", "-")
+		}
+	}
+	return yaml.Marshal(table)
+}
+
+// subTable returns the yaml of the table at the dot-separated key path in data
+func subTable(data []byte, key string) ([]byte, error) {
+	var table interface{}
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return nil, err
+	}
+
+	for _, name := range strings.Split(key, ".") {
+		m, ok := table.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("no %q table", key)
+		}
+		if table, ok = m[name]; !ok {
+			return nil, fmt.Errorf("no %q table", key)
+		}
+	}
+	if _, ok := table.(map[interface{}]interface{}); !ok {
+		return nil, fmt.Errorf("%q is not a table", key)
+	}
+	return yaml.Marshal(table)
+}
+
 // ApplySettings updates the Config with values from the settings file
 // but only for flags that were not explicitly set on the command line
 func ApplySettings(c *Config, s Settings, passedFlags map[string]bool) {
@@ -138,4 +352,181 @@ func ApplySettings(c *Config, s Settings, passedFlags map[string]bool) {
 	if s.SortVars != nil && !passedFlags["sort-vars"] {
 		c.SortVars = *s.SortVars
 	}
+	if s.SortData != nil && !passedFlags["sort-data"] {
+		c.SortData = *s.SortData
+	}
+	if s.SortOutputs != nil && !passedFlags["sort-outputs"] {
+		c.SortOutputs = *s.SortOutputs
+	}
+	if s.FixHeredocIndent != nil && !passedFlags["fix-heredoc-indent"] {
+		c.FixHeredocIndent = *s.FixHeredocIndent
+	}
+	if s.CacheFile != nil && !passedFlags["cache"] {
+		c.CacheFile = *s.CacheFile
+	}
+	if s.Tofu != nil && !passedFlags["tofu"] {
+		c.Tofu = *s.Tofu
+	}
+	if s.CheckNaming != nil && !passedFlags["check-naming"] {
+		c.CheckNaming = *s.CheckNaming
+	}
+	if s.NamingPattern != nil && !passedFlags["naming-pattern"] {
+		c.NamingPattern = *s.NamingPattern
+	}
+	if s.CommentStyle != nil && !passedFlags["comment-style"] {
+		c.CommentStyle = *s.CommentStyle
+	}
+	if s.PreserveAttrs != nil && !passedFlags["preserve-attrs"] {
+		c.PreserveAttrs = s.PreserveAttrs
+	}
+	if s.AlignScope != nil && !passedFlags["align-scope"] {
+		c.AlignScope = *s.AlignScope
+	}
+	if s.CheckBackend != nil && !passedFlags["check-backend"] {
+		c.CheckBackend = *s.CheckBackend
+	}
+	if s.NoSortCommentBlocks != nil && !passedFlags["no-sort-comment-blocks"] {
+		c.NoSortCommentBlocks = *s.NoSortCommentBlocks
+	}
+	if s.CanonicalDynamic != nil && !passedFlags["canonical-dynamic"] {
+		c.CanonicalDynamic = *s.CanonicalDynamic
+	}
+	if s.FailFast != nil && !passedFlags["fail-fast"] {
+		c.FailFast = *s.FailFast
+	}
+	if s.ListUnchanged != nil && !passedFlags["list-unchanged"] {
+		c.ListUnchanged = *s.ListUnchanged
+	}
+	if s.NoFollowSymlinks != nil && !passedFlags["no-follow-symlink-write"] {
+		c.NoFollowSymlinks = *s.NoFollowSymlinks
+	}
+	if s.NormalizeEOLInStrings != nil && !passedFlags["normalize-eol-within-strings"] {
+		c.NormalizeEOLInStrings = *s.NormalizeEOLInStrings
+	}
+	if s.NoReorderTypes != nil && !passedFlags["no-reorder-types"] {
+		c.NoReorderTypes = s.NoReorderTypes
+	}
+	if s.MergeLocals != nil && !passedFlags["merge-locals"] {
+		c.MergeLocals = *s.MergeLocals
+	}
+	if s.SortLocals != nil && !passedFlags["sort-locals"] {
+		c.SortLocals = *s.SortLocals
+	}
+	if s.Modernize != nil && !passedFlags["modernize"] {
+		c.Modernize = *s.Modernize
+	}
+	if s.DiffFormat != nil && !passedFlags["diff-format"] {
+		c.DiffFormat = *s.DiffFormat
+	}
+	if s.SkipGenerated != nil && !passedFlags["skip-generated"] {
+		c.SkipGenerated = *s.SkipGenerated
+	}
+	if s.GeneratedMarker != nil && !passedFlags["generated-marker"] {
+		c.GeneratedMarker = *s.GeneratedMarker
+	}
+	if s.GroupList != nil && !passedFlags["group-list"] {
+		c.GroupList = *s.GroupList
+	}
+	if s.NoColor != nil && !passedFlags["no-color"] {
+		c.NoColor = *s.NoColor
+	}
+	if s.CheckProviderRefs != nil && !passedFlags["check-provider-refs"] {
+		c.CheckProviderRefs = *s.CheckProviderRefs
+	}
+	if s.NormalizeBoolAttrs != nil && !passedFlags["normalize-bool-attrs"] {
+		c.NormalizeBoolAttrs = s.NormalizeBoolAttrs
+	}
+	if s.VarsFirst != nil && !passedFlags["vars-first"] {
+		c.VarsFirst = s.VarsFirst
+	}
+	if s.AttributeOrder != nil && !passedFlags["attribute-order"] {
+		c.AttributeOrder = s.AttributeOrder
+	}
+	if s.IgnoreSortInCheck != nil && !passedFlags["ignore-sort-in-check"] {
+		c.IgnoreSortInCheck = *s.IgnoreSortInCheck
+	}
+	if s.WrapCalls != nil && !passedFlags["wrap-calls"] {
+		c.WrapCalls = *s.WrapCalls
+	}
+	if s.SortCaseInsensitive != nil && !passedFlags["sort-case-insensitive"] {
+		c.SortCaseInsensitive = *s.SortCaseInsensitive
+	}
+	if s.Include != nil && !passedFlags["include"] {
+		c.Include = s.Include
+	}
+	if s.Verify != nil && !passedFlags["verify"] {
+		c.Verify = *s.Verify
+	}
+	if s.DiffByBlock != nil && !passedFlags["diff-by-block"] {
+		c.DiffByBlock = *s.DiffByBlock
+	}
+	if s.Explain != nil && !passedFlags["explain"] {
+		c.Explain = *s.Explain
+	}
+	if s.SkipUnsafePasses != nil && !passedFlags["skip-unsafe-passes"] {
+		c.SkipUnsafePasses = *s.SkipUnsafePasses
+	}
+	if s.NormalizeListSpacing != nil && !passedFlags["normalize-list-spacing"] {
+		c.NormalizeListSpacing = *s.NormalizeListSpacing
+	}
+	if s.IgnoreTrailingNewlines != nil && !passedFlags["ignore-trailing-newlines"] {
+		c.IgnoreTrailingNewlines = *s.IgnoreTrailingNewlines
+	}
+	if s.MaxFileSize != nil && !passedFlags["max-file-size"] {
+		c.MaxFileSize = *s.MaxFileSize
+	}
+	if s.Oversized != nil && !passedFlags["oversized"] {
+		c.Oversized = *s.Oversized
+	}
+	if s.NormalizeEscapes != nil && !passedFlags["normalize-escapes"] {
+		c.NormalizeEscapes = *s.NormalizeEscapes
+	}
+	if s.Touch != nil && !passedFlags["touch"] {
+		c.Touch = *s.Touch
+	}
+	if s.Indent != nil && !passedFlags["indent"] {
+		c.Indent = *s.Indent
+	}
+	if s.BlockSpacing != nil && !passedFlags["block-spacing"] {
+		c.BlockSpacing = *s.BlockSpacing
+	}
+	if s.NormalizeProviderVersions != nil && !passedFlags["normalize-provider-versions"] {
+		c.NormalizeProviderVersions = *s.NormalizeProviderVersions
+	}
+	if s.IgnoreCommentChanges != nil && !passedFlags["ignore-comment-changes"] {
+		c.IgnoreCommentChanges = *s.IgnoreCommentChanges
+	}
+	if s.FoldDescriptions != nil && !passedFlags["fold-descriptions"] {
+		c.FoldDescriptions = *s.FoldDescriptions
+	}
+	if s.VerifyNoop != nil && !passedFlags["verify-noop"] {
+		c.VerifyNoop = *s.VerifyNoop
+	}
+	if s.CheckEmptyDefaults != nil && !passedFlags["check-empty-defaults"] {
+		c.CheckEmptyDefaults = *s.CheckEmptyDefaults
+	}
+	if s.AlignTrailingComments != nil && !passedFlags["align-trailing-comments"] {
+		c.AlignTrailingComments = *s.AlignTrailingComments
+	}
+	if s.CheckAlignment != nil && !passedFlags["check-alignment"] {
+		c.CheckAlignment = *s.CheckAlignment
+	}
+	if s.PreserveInterleaving != nil && !passedFlags["preserve-interleaving"] {
+		c.PreserveInterleaving = *s.PreserveInterleaving
+	}
+	if s.CountLines != nil && !passedFlags["count-lines"] {
+		c.CountLines = *s.CountLines
+	}
+	if s.OnlyTracked != nil && !passedFlags["only-tracked"] {
+		c.OnlyTracked = *s.OnlyTracked
+	}
+	if s.CanonicalLifecycle != nil && !passedFlags["canonical-lifecycle"] {
+		c.CanonicalLifecycle = *s.CanonicalLifecycle
+	}
+	if s.FirstDiffLine != nil && !passedFlags["first-diff-line"] {
+		c.FirstDiffLine = *s.FirstDiffLine
+	}
+	if s.PreserveBlankLinesInComments != nil && !passedFlags["preserve-blank-lines-in-comments"] {
+		c.PreserveBlankLinesInComments = *s.PreserveBlankLinesInComments
+	}
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -151,40 +152,100 @@ func TestFindConfigFile(t *testing.T) {
 	}
 	defer os.Chdir(currentDir) // Make sure we go back to the original directory
 
-	// Use a local FindConfigFile implementation for testing
-	// Rather than trying to modify the package function directly
-	findConfigFileTest := func() string {
-		// Mini implementation that just checks the current and parent dirs
-		// without going all the way to home directory
-		if _, err := os.Stat(".tffmt.yml"); err == nil {
-			return ".tffmt.yml"
-		}
+	found, err := filepath.EvalSymlinks(FindConfigFile())
+	if err != nil {
+		t.Fatalf("FindConfigFile() did not find the config file: %v", err)
+	}
+	want, err := filepath.EvalSymlinks(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != want {
+		t.Errorf("FindConfigFile() = %q, want %q", found, want)
+	}
+}
+
+func TestLoadSettingsFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".project.yml")
+	content := `lint:
+  strict: true
+tools:
+  tffmt:
+    write: false
+    sort-vars: true
+    naming-pattern: "^[a-z]+$"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
 
-		dir, err := os.Getwd()
-		if err != nil {
-			return ""
+	settings, err := LoadSettingsFile(configPath, "tools.tffmt")
+	if err != nil {
+		t.Fatalf("LoadSettingsFile() error = %v", err)
+	}
+	if settings.Write == nil || *settings.Write {
+		t.Errorf("Write = %v, want false", settings.Write)
+	}
+	if settings.SortVars == nil || !*settings.SortVars {
+		t.Errorf("SortVars = %v, want true", settings.SortVars)
+	}
+	if settings.NamingPattern == nil || *settings.NamingPattern != "^[a-z]+$" {
+		t.Errorf("NamingPattern = %v, want ^[a-z]+$", settings.NamingPattern)
+	}
+
+	for _, key := range []string{"tools.missing", "lint.strict"} {
+		if _, err := LoadSettingsFile(configPath, key); err == nil {
+			t.Errorf("LoadSettingsFile() with key %q succeeded, want error", key)
 		}
+	}
+}
 
-		for i := 0; i < 3; i++ { // Only check a few levels up
-			parentDir := filepath.Dir(dir)
-			if parentDir == dir {
-				break
-			}
+// TestLoadSettingsUnderscores verifies settings keys may be written with
+// underscores instead of hyphens
+func TestLoadSettingsUnderscores(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".tffmt.yml")
+	if err := os.WriteFile(configPath, []byte("block_spacing: 1\nsort-inputs: true\nattribute_order: [name, description, \"*\", tags]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
 
-			path := filepath.Join(parentDir, ".tffmt.yml")
-			if _, err := os.Stat(path); err == nil {
-				return path
-			}
+	settings, err := LoadSettingsFile(configPath, "")
+	if err != nil {
+		t.Fatalf("LoadSettingsFile() error = %v", err)
+	}
+	if settings.BlockSpacing == nil || *settings.BlockSpacing != 1 {
+		t.Errorf("BlockSpacing = %v, want 1", settings.BlockSpacing)
+	}
+	if settings.SortInputs == nil || !*settings.SortInputs {
+		t.Errorf("SortInputs = %v, want true", settings.SortInputs)
+	}
+	if want := []string{"name", "description", "*", "tags"}; !reflect.DeepEqual(settings.AttributeOrder, want) {
+		t.Errorf("AttributeOrder = %q, want %q", settings.AttributeOrder, want)
+	}
+}
 
-			dir = parentDir
+// TestClone verifies a cloned config is equal to the original and shares
+// none of its slices
+func TestClone(t *testing.T) {
+	c := NewConfig()
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Kind() == reflect.Slice {
+			f.Set(reflect.ValueOf([]string{"a"}))
 		}
+	}
 
-		return ""
+	clone := c.Clone()
+	if !reflect.DeepEqual(clone, c) {
+		t.Fatalf("Clone() = %+v, want %+v", clone, c)
 	}
 
-	found := findConfigFileTest()
-	if found == "" {
-		t.Errorf("FindConfigFile() did not find the config file")
+	// Every slice must be copied, or writing to it changes c as well
+	cv := reflect.ValueOf(clone).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := cv.Field(i); f.Kind() == reflect.Slice && f.Pointer() == v.Field(i).Pointer() {
+			t.Errorf("Clone() shares %s with the original", v.Type().Field(i).Name)
+		}
 	}
 }
 
@@ -192,3 +253,13 @@ func TestFindConfigFile(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+// Helper function to return a pointer to an int
+func intPtr(n int) *int {
+	return &n
+}
+
+// Helper function to return a pointer to a string
+func stringPtr(s string) *string {
+	return &s
+}
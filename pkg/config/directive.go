@@ -0,0 +1,47 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// reDirective matches a "# tffmt: key=value ..." directive comment
+var reDirective = regexp.MustCompile(`^(?:#|//)\s*tffmt:(.*)$`)
+
+// Directive returns the settings of the directive on the first line of
+// content, like "# tffmt: indent=4 blank-lines=2". Its keys are those of a
+// settings file, plus blank-lines as another name for block-spacing. It
+// reports false when content has no directive.
+func Directive(content []byte) (Settings, bool, error) {
+	line, _, _ := bytes.Cut(content, []byte("\n"))
+	m := reDirective.FindSubmatch(bytes.TrimSpace(line))
+	if m == nil {
+		return Settings{}, false, nil
+	}
+
+	var doc strings.Builder
+	for _, field := range strings.Fields(string(m[1])) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return Settings{}, true, fmt.Errorf("invalid tffmt directive %q: want key=value", field)
+		}
+		if strings.ReplaceAll(key, "_", "-") == "blank-lines" {
+			key = "block-spacing"
+		}
+		fmt.Fprintf(&doc, "%s: %s\n", key, value)
+	}
+
+	data, err := hyphenateKeys([]byte(doc.String()))
+	if err != nil {
+		return Settings{}, true, fmt.Errorf("invalid tffmt directive: %w", err)
+	}
+	var settings Settings
+	if err := yaml.UnmarshalStrict(data, &settings); err != nil {
+		return Settings{}, true, fmt.Errorf("invalid tffmt directive: %w", err)
+	}
+	return settings, true, nil
+}
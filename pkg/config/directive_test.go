@@ -0,0 +1,39 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDirective verifies the settings of a first-line directive are read
+// like those of a settings file
+func TestDirective(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected Settings
+		found    bool
+		wantErr  bool
+	}{
+		{"none", "resource \"a\" \"b\" {}\n", Settings{}, false, false},
+		{"not on the first line", "\n# tffmt: indent=4\n", Settings{}, false, false},
+		{"hash", "# tffmt: indent=4 sort-inputs=true\n", Settings{Indent: intPtr(4), SortInputs: boolPtr(true)}, true, false},
+		{"blank lines", "# tffmt: indent=4 blank-lines=2\n", Settings{Indent: intPtr(4), BlockSpacing: intPtr(2)}, true, false},
+		{"underscores", "# tffmt: sort_inputs=true blank_lines=1\n", Settings{SortInputs: boolPtr(true), BlockSpacing: intPtr(1)}, true, false},
+		{"slash", "//tffmt: comment-style=slash", Settings{CommentStyle: stringPtr("slash")}, true, false},
+		{"unknown key", "# tffmt: indentation=4\n", Settings{}, true, true},
+		{"missing value", "# tffmt: indent\n", Settings{}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, found, err := Directive([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Directive() error = %v, want error: %v", err, tt.wantErr)
+			}
+			if found != tt.found || !reflect.DeepEqual(settings, tt.expected) {
+				t.Errorf("Directive() = %+v, %v, want %+v, %v", settings, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
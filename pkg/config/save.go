@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SettingsFrom returns the settings that reproduce c, holding every value
+// of c a settings file can set that differs from the defaults of NewConfig
+func SettingsFrom(c *Config) Settings {
+	var s Settings
+	settings := reflect.ValueOf(&s).Elem()
+	current := reflect.ValueOf(c).Elem()
+	defaults := reflect.ValueOf(NewConfig()).Elem()
+	for i := range settings.NumField() {
+		name := settings.Type().Field(i).Name
+		value := current.FieldByName(name)
+		if reflect.DeepEqual(value.Interface(), defaults.FieldByName(name).Interface()) {
+			continue
+		}
+
+		field := settings.Field(i)
+		if field.Kind() == reflect.Pointer {
+			ptr := reflect.New(field.Type().Elem())
+			ptr.Elem().Set(value)
+			field.Set(ptr)
+		} else {
+			field.Set(value)
+		}
+	}
+	return s
+}
+
+// MarshalSettings renders the settings that are set as yaml, at the
+// dot-separated key path when key is not empty, so LoadSettingsFile reads
+// them back
+func MarshalSettings(s Settings, key string) ([]byte, error) {
+	var table yaml.MapSlice
+	settings := reflect.ValueOf(s)
+	for i := range settings.NumField() {
+		field := settings.Field(i)
+		if field.IsNil() {
+			continue
+		}
+		name, _, _ := strings.Cut(settings.Type().Field(i).Tag.Get("yaml"), ",")
+		table = append(table, yaml.MapItem{Key: name, Value: field.Interface()})
+	}
+
+	var doc any = table
+	if key != "" {
+		names := strings.Split(key, ".")
+		for i := len(names) - 1; i >= 0; i-- {
+			doc = yaml.MapSlice{{Key: names[i], Value: doc}}
+		}
+	}
+	return yaml.Marshal(doc)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestSettingsFieldsMatchConfig verifies every setting has a Config field
+// of the same name and type, which SettingsFrom relies on
+func TestSettingsFieldsMatchConfig(t *testing.T) {
+	config := reflect.TypeOf(Config{})
+	settings := reflect.TypeOf(Settings{})
+	for i := range settings.NumField() {
+		field := settings.Field(i)
+		want := field.Type
+		if want.Kind() == reflect.Pointer {
+			want = want.Elem()
+		}
+		if c, ok := config.FieldByName(field.Name); !ok || c.Type != want {
+			t.Errorf("Settings.%s has no Config field of type %s", field.Name, want)
+		}
+	}
+}
+
+// TestSaveSettingsRoundTrip verifies saved settings load back into the
+// same Config
+func TestSaveSettingsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"top level", ""},
+		{"nested table", "tools.tffmt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := NewConfig()
+			saved.SortInputs = true
+			saved.Write = false
+			saved.CommentStyle = "slash"
+			saved.VarsFirst = []string{"region"}
+			saved.WrapCalls = 80
+
+			data, err := MarshalSettings(SettingsFrom(saved), tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), ".tffmt.yml")
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			settings, err := LoadSettingsFile(path, tt.key)
+			if err != nil {
+				t.Fatalf("LoadSettingsFile() error = %v\n%s", err, data)
+			}
+			loaded := NewConfig()
+			ApplySettings(loaded, settings, map[string]bool{})
+			if !reflect.DeepEqual(loaded, saved) {
+				t.Errorf("reloaded config = %+v, want %+v\n%s", loaded, saved, data)
+			}
+		})
+	}
+}
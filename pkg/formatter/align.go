@@ -0,0 +1,169 @@
+package formatter
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// alignedAttr is a single-line attribute whose "=" can be aligned
+type alignedAttr struct {
+	scope   int // token index of the enclosing "{", or -1 at the top level
+	column  int // width from the start of the line to the end of the name
+	nameEnd int // byte offset just past the attribute name
+	eqStart int // byte offset of the "="
+}
+
+// alignBlockScope aligns the "=" of every single-line attribute in a body
+// to one column, instead of restarting alignment after each blank line or
+// nested block as hclwrite does. Attributes whose values span several
+// lines are not aligned, matching hclwrite.
+func alignBlockScope(in []byte) []byte {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+
+	var attrs []alignedAttr
+	var stack []int
+	var pending *alignedAttr
+	pendingDepth := 0
+	lineStart, atLineStart := 0, true
+
+	for i, tok := range tokens {
+		if atLineStart && tok.Type == hclsyntax.TokenIdent && i+1 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenEqual {
+			scope := -1
+			if len(stack) > 0 {
+				scope = stack[len(stack)-1]
+			}
+			if scope < 0 || tokens[scope].Type == hclsyntax.TokenOBrace {
+				pending = &alignedAttr{
+					scope:   scope,
+					column:  tok.Range.End.Byte - lineStart,
+					nameEnd: tok.Range.End.Byte,
+					eqStart: tokens[i+1].Range.Start.Byte,
+				}
+				pendingDepth = len(stack)
+			}
+		}
+		atLineStart = false
+
+		switch tok.Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen,
+			hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl, hclsyntax.TokenOHeredoc:
+			stack = append(stack, i)
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen,
+			hclsyntax.TokenTemplateSeqEnd, hclsyntax.TokenCHeredoc:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case hclsyntax.TokenNewline:
+			if pending != nil && len(stack) == pendingDepth {
+				attrs = append(attrs, *pending)
+			}
+			pending = nil
+			lineStart, atLineStart = tok.Range.End.Byte, true
+		}
+	}
+
+	// Find the widest attribute name in each scope
+	widths := make(map[int]int)
+	for _, a := range attrs {
+		if a.column > widths[a.scope] {
+			widths[a.scope] = a.column
+		}
+	}
+
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].nameEnd < attrs[j].nameEnd })
+	var out bytes.Buffer
+	last := 0
+	for _, a := range attrs {
+		out.Write(in[last:a.nameEnd])
+		out.Write(bytes.Repeat([]byte(" "), widths[a.scope]-a.column+1))
+		last = a.eqStart
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
+
+// trailingComment is a comment following code on the same line
+type trailingComment struct {
+	scope   int // token index of the enclosing bracket, or -1 at the top level
+	column  int // width from the start of the line to the end of the code
+	codeEnd int // byte offset just past the code before the comment
+	start   int // byte offset of the comment
+}
+
+// alignTrailingComments starts the trailing comments of a block at one
+// column, one space past its longest line of code, instead of restarting
+// alignment at each blank line or line without a comment as hclwrite does.
+// Comments are aligned per bracketed scope, and only the spaces before
+// them change.
+func alignTrailingComments(in []byte) []byte {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+
+	var comments []trailingComment
+	var stack []int
+	lineStart := 0
+	for i, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen,
+			hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl, hclsyntax.TokenOHeredoc:
+			stack = append(stack, i)
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen,
+			hclsyntax.TokenTemplateSeqEnd, hclsyntax.TokenCHeredoc:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case hclsyntax.TokenNewline:
+			lineStart = tok.Range.End.Byte
+		case hclsyntax.TokenComment:
+			if i > 0 && tokens[i-1].Type != hclsyntax.TokenNewline && tokens[i-1].Type != hclsyntax.TokenComment &&
+				tokens[i-1].Range.End.Line == tok.Range.Start.Line {
+				scope := -1
+				if len(stack) > 0 {
+					scope = stack[len(stack)-1]
+				}
+				codeEnd := tokens[i-1].Range.End.Byte
+				comments = append(comments, trailingComment{
+					scope:   scope,
+					column:  utf8.RuneCount(in[lineStart:codeEnd]),
+					codeEnd: codeEnd,
+					start:   tok.Range.Start.Byte,
+				})
+			}
+			// Line comments hold their newline
+			if bytes.HasSuffix(tok.Bytes, []byte("\n")) {
+				lineStart = tok.Range.End.Byte
+			}
+		}
+	}
+	if len(comments) == 0 {
+		return in
+	}
+
+	// Find the longest line of code in each scope
+	widths := make(map[int]int)
+	for _, c := range comments {
+		if c.column > widths[c.scope] {
+			widths[c.scope] = c.column
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool { return comments[i].start < comments[j].start })
+	var out bytes.Buffer
+	last := 0
+	for _, c := range comments {
+		out.Write(in[last:c.codeEnd])
+		out.Write(bytes.Repeat([]byte(" "), widths[c.scope]-c.column+1))
+		last = c.start
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
@@ -0,0 +1,143 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestAlignScope verifies attribute alignment per blank-line group and per block
+func TestAlignScope(t *testing.T) {
+	input := `resource "aws_instance" "web" {
+  ami = "ami-12345"
+  instance_type = "t2.micro"
+
+  monitoring = true
+
+  tags = {
+    Name = "web"
+    Environment = "prod"
+  }
+  user_data = file("init.sh")
+}
+`
+	tests := []struct {
+		scope    string
+		expected string
+	}{
+		{
+			scope: "group",
+			expected: `resource "aws_instance" "web" {
+  ami           = "ami-12345"
+  instance_type = "t2.micro"
+
+  monitoring = true
+
+  tags = {
+    Name        = "web"
+    Environment = "prod"
+  }
+
+  user_data = file("init.sh")
+}
+
+`,
+		},
+		{
+			scope: "block",
+			expected: `resource "aws_instance" "web" {
+  ami           = "ami-12345"
+  instance_type = "t2.micro"
+
+  monitoring    = true
+
+  tags = {
+    Name        = "web"
+    Environment = "prod"
+  }
+
+  user_data     = file("init.sh")
+}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.AlignScope = tt.scope
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() with align-scope=%s =\n%s\nwant:\n%s", tt.scope, formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestAlignTrailingComments verifies trailing comments are aligned per
+// block and that blocks without them are left alone
+func TestAlignTrailingComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "comments",
+			input: `resource "aws_instance" "web" {
+  ami = "ami-12345" # the image
+  instance_type = "t2.micro" # size
+
+  monitoring = true
+  user_data = file("init.sh") # see "#docs"
+  tags = {
+    Name = "web" # name
+    Environment = "prod"
+  }
+}
+`,
+			expected: `resource "aws_instance" "web" {
+  ami           = "ami-12345"  # the image
+  instance_type = "t2.micro"   # size
+
+  monitoring = true
+  user_data  = file("init.sh") # see "#docs"
+  tags = {
+    Name        = "web" # name
+    Environment = "prod"
+  }
+
+}
+
+`,
+		},
+		{
+			name: "no comments",
+			input: `resource "aws_instance" "web" {
+  ami = "ami-12345"
+  instance_type = "t2.micro"
+}
+`,
+			expected: `resource "aws_instance" "web" {
+  ami           = "ami-12345"
+  instance_type = "t2.micro"
+}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.AlignTrailingComments = true
+			formatted := New(cfg).Format([]byte(tt.input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() =\n%s\nwant:\n%s", formatted, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package formatter
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// blockSpan is the source of a top-level block, from the first of the
+// comment lines directly above it to the end of its closing line
+type blockSpan struct {
+	block      *hclsyntax.Block
+	start, end int
+}
+
+// topLevelSpans returns the spans of the top-level blocks in src, in
+// source order
+func topLevelSpans(src []byte) ([]blockSpan, bool) {
+	file, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+
+	spans := make([]blockSpan, 0, len(body.Blocks))
+	for _, block := range body.Blocks {
+		rng := block.Range()
+		end := rng.End.Byte
+		if nl := bytes.IndexByte(src[end:], '\n'); nl >= 0 {
+			end += nl + 1
+		} else {
+			end = len(src)
+		}
+		spans = append(spans, blockSpan{
+			block: block,
+			start: leadCommentStart(src, rng.Start.Byte),
+			end:   end,
+		})
+	}
+	return spans, true
+}
+
+// leadCommentStart walks back from the line starting at offset over any
+// comments directly above it, and returns where the first one starts.
+// Block comments count when they have their lines to themselves.
+func leadCommentStart(src []byte, offset int) int {
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	for start > 0 {
+		prev := bytes.LastIndexByte(src[:start-1], '\n') + 1
+		line := bytes.TrimSpace(src[prev : start-1])
+		if bytes.HasSuffix(line, []byte("*/")) {
+			open := bytes.LastIndex(src[:start-1], []byte("/*"))
+			if open < 0 {
+				break
+			}
+			openLine := bytes.LastIndexByte(src[:open], '\n') + 1
+			if len(bytes.TrimSpace(src[openLine:open])) > 0 {
+				break
+			}
+			start = openLine
+			continue
+		}
+		if !bytes.HasPrefix(line, []byte("#")) && !bytes.HasPrefix(line, []byte("//")) {
+			break
+		}
+		start = prev
+	}
+	return start
+}
+
+// hasComment reports whether src contains a comment token
+func hasComment(src []byte) bool {
+	tokens, _ := hclsyntax.LexConfig(src, "", hcl.InitialPos)
+	for _, tok := range tokens {
+		if tok.Type == hclsyntax.TokenComment {
+			return true
+		}
+	}
+	return false
+}
+
+// sortBlocks reorders the top-level blocks selected by match according to
+// less. The sorted blocks fill the positions the selected blocks had, and
+// every other byte of src, including the blank lines between blocks,
+// stays where it is. Comments directly above a block move with it. When
+// sections is set, standalone comments split the file into sections that
+// are sorted independently, so blocks never move across a divider.
+func sortBlocks(src []byte, match func(*hclsyntax.Block) bool, less func(a, b *hclsyntax.Block) bool, sections bool) []byte {
+	spans, ok := topLevelSpans(src)
+	if !ok {
+		return src
+	}
+
+	// Group the selected blocks into sections of slots
+	var groups [][]blockSpan
+	var current []blockSpan
+	prevEnd := 0
+	for _, span := range spans {
+		if sections && hasComment(src[prevEnd:span.start]) && len(current) > 0 {
+			groups = append(groups, current)
+			current = nil
+		}
+		prevEnd = span.end
+		if match(span.block) {
+			current = append(current, span)
+		}
+	}
+	groups = append(groups, current)
+
+	var out bytes.Buffer
+	last := 0
+	for _, slots := range groups {
+		sorted := append([]blockSpan(nil), slots...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return less(sorted[i].block, sorted[j].block)
+		})
+		for i, slot := range slots {
+			out.Write(src[last:slot.start])
+			text := src[sorted[i].start:sorted[i].end]
+			out.Write(text)
+			// The last block of a file may lack a newline of its own
+			if !bytes.HasSuffix(text, []byte("\n")) {
+				out.WriteByte('\n')
+			}
+			last = slot.end
+		}
+	}
+	out.Write(src[last:])
+	return out.Bytes()
+}
+
+// nameLess returns the order names are sorted in. Under caseInsensitive,
+// names are compared case-folded first, and names that fold to the same
+// string are ordered by their original case, so "Foo" sorts before "foo".
+func nameLess(caseInsensitive bool) func(a, b string) bool {
+	return func(a, b string) bool {
+		if caseInsensitive {
+			if la, lb := strings.ToLower(a), strings.ToLower(b); la != lb {
+				return la < lb
+			}
+		}
+		return a < b
+	}
+}
+
+// firstLabelLess orders blocks by their first label, keeping blocks without
+// labels where they are
+func firstLabelLess(less func(a, b string) bool) func(a, b *hclsyntax.Block) bool {
+	return func(a, b *hclsyntax.Block) bool {
+		if len(a.Labels) == 0 || len(b.Labels) == 0 {
+			return false
+		}
+		return less(a.Labels[0], b.Labels[0])
+	}
+}
+
+// labelsLess orders blocks by their labels joined by dots, like
+// "aws_ami.ubuntu" for a data block
+func labelsLess(less func(a, b string) bool) func(a, b *hclsyntax.Block) bool {
+	return func(a, b *hclsyntax.Block) bool {
+		return less(strings.Join(a.Labels, "."), strings.Join(b.Labels, "."))
+	}
+}
+
+// pinnedFirstLess orders blocks whose first label is in pinned first, in
+// the order given, and the rest after them by their first label
+func pinnedFirstLess(pinned []string, less func(a, b string) bool) func(a, b *hclsyntax.Block) bool {
+	rank := func(block *hclsyntax.Block) int {
+		if i := slices.Index(pinned, block.Labels[0]); i >= 0 {
+			return i
+		}
+		return len(pinned)
+	}
+	return func(a, b *hclsyntax.Block) bool {
+		if len(a.Labels) == 0 || len(b.Labels) == 0 {
+			return false
+		}
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		return less(a.Labels[0], b.Labels[0])
+	}
+}
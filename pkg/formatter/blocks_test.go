@@ -0,0 +1,322 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestSortVarsSections verifies variables are sorted within comment-delimited
+// sections when no-sort-comment-blocks is set
+func TestSortVarsSections(t *testing.T) {
+	input := `# ---- networking ----
+
+variable "vpc_id" {}
+
+variable "subnet_ids" {}
+
+# ---- compute ----
+
+variable "instance_type" {}
+
+# AMI to boot
+variable "ami" {}
+`
+	tests := []struct {
+		name     string
+		sections bool
+		expected string
+	}{
+		{
+			name:     "sections sorted independently",
+			sections: true,
+			expected: `# ---- networking ----
+
+variable "subnet_ids" {}
+
+variable "vpc_id" {}
+
+# ---- compute ----
+
+# AMI to boot
+variable "ami" {}
+
+variable "instance_type" {}
+
+`,
+		},
+		{
+			name:     "sorted across the whole file",
+			sections: false,
+			expected: `# ---- networking ----
+
+# AMI to boot
+variable "ami" {}
+
+variable "instance_type" {}
+
+# ---- compute ----
+
+variable "subnet_ids" {}
+
+variable "vpc_id" {}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.SortVars = true
+			cfg.NoSortCommentBlocks = tt.sections
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() with no-sort-comment-blocks=%v =\n%s\nwant:\n%s", tt.sections, formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSortBlocksMissingNewline verifies a final block without a trailing
+// newline can be moved ahead of other blocks
+func TestSortBlocksMissingNewline(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SortVars = true
+	formatter := New(cfg)
+
+	formatted := formatter.Format([]byte("variable \"b\" {}\nvariable \"a\" {}"))
+	expected := "variable \"a\" {}\n\nvariable \"b\" {}\n\n"
+	if string(formatted) != expected {
+		t.Errorf("Format() = %q, want %q", formatted, expected)
+	}
+}
+
+// TestSortVarsNestedBlocks verifies variables with validation blocks and
+// nullable attributes keep their whole content, in its original order,
+// when they are sorted
+func TestSortVarsNestedBlocks(t *testing.T) {
+	environment := `variable "environment" {
+  type     = string
+  nullable = false
+
+  validation {
+    condition     = contains(["dev", "prod"], var.environment)
+    error_message = "Environment must be dev or prod."
+  }
+  description = "Deployment environment"
+}
+`
+	ami := `variable "ami" {
+  description = "AMI ID to use"
+  sensitive   = true
+  default     = null
+
+  validation {
+    condition     = var.ami == null || startswith(var.ami, "ami-")
+    error_message = "AMI IDs start with ami-."
+  }
+
+  validation {
+    condition     = var.ami == null || length(var.ami) > 4
+    error_message = "AMI ID is too short."
+  }
+  nullable = true
+}
+`
+	cfg := config.NewConfig()
+	cfg.SortVars = true
+	formatter := New(cfg)
+
+	sorted := formatter.sortVariableBlocks([]byte(environment + "\n" + ami))
+	expected := ami + "\n" + environment
+	if string(sorted) != expected {
+		t.Errorf("sortVariableBlocks() = %q, want %q", sorted, expected)
+	}
+}
+
+// TestVarsFirst verifies pinned variables are sorted ahead of the
+// alphabetical remainder, in the order they were given
+func TestVarsFirst(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SortVars = true
+	cfg.VarsFirst = []string{"region", "environment"}
+	formatter := New(cfg)
+
+	input := "variable \"zone\" {}\n\nvariable \"environment\" {}\n\nvariable \"ami\" {}\n\nvariable \"region\" {}\n\nvariable \"bucket\" {}\n"
+	expected := "variable \"region\" {}\n\nvariable \"environment\" {}\n\nvariable \"ami\" {}\n\nvariable \"bucket\" {}\n\nvariable \"zone\" {}\n\n"
+	if formatted := formatter.Format([]byte(input)); string(formatted) != expected {
+		t.Errorf("Format() = %q, want %q", formatted, expected)
+	}
+}
+
+// TestSortData verifies data blocks are sorted by type then name, taking
+// TestSortVarsComments verifies the comments above each variable move
+// with it when sorting, whatever their style
+func TestSortVarsComments(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SortVars = true
+	formatter := New(cfg)
+
+	input := `# Deployment zone
+variable "zone" {
+  type = string
+}
+
+/* AMI to boot,
+   by id */
+variable "ami" {
+  type = string
+}
+
+// Size of the instance
+# at least t3
+variable "instance_type" {
+  type = string
+}
+`
+	expected := `/* AMI to boot,
+   by id */
+variable "ami" {
+  type = string
+}
+
+# Size of the instance
+# at least t3
+variable "instance_type" {
+  type = string
+}
+
+# Deployment zone
+variable "zone" {
+  type = string
+}
+
+`
+	if formatted := formatter.Format([]byte(input)); string(formatted) != expected {
+		t.Errorf("Format() = %q, want %q", formatted, expected)
+	}
+}
+
+// the comments above them along, while other blocks keep their places
+func TestSortData(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SortData = true
+	formatter := New(cfg)
+
+	input := `# The newest ubuntu image
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+data "aws_vpc" "main" {
+  default = true
+}
+
+resource "aws_instance" "web" {
+  ami = data.aws_ami.ubuntu.id
+}
+
+data "aws_ami" "amazon_linux" {
+  owners = ["amazon"]
+}
+
+data "aws_availability_zones" "available" {}
+`
+	expected := `data "aws_ami" "amazon_linux" {
+  owners = ["amazon"]
+}
+
+# The newest ubuntu image
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+resource "aws_instance" "web" {
+  ami = data.aws_ami.ubuntu.id
+}
+
+data "aws_availability_zones" "available" {}
+
+data "aws_vpc" "main" {
+  default = true
+}
+
+`
+	if formatted := formatter.Format([]byte(input)); string(formatted) != expected {
+		t.Errorf("Format() = %q, want %q", formatted, expected)
+	}
+}
+
+// TestSortOutputs verifies output blocks are sorted by name, keeping their
+// comments, and that a single output stays where it is
+func TestSortOutputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "sorted",
+			input: `output "vpc_id" {
+  value = aws_vpc.main.id
+}
+
+# The subnets, in zone order
+output "subnet_ids" {
+  value = aws_subnet.main[*].id
+}
+
+resource "aws_vpc" "main" {}
+
+output "arn" {
+  value = aws_vpc.main.arn
+}
+`,
+			expected: `output "arn" {
+  value = aws_vpc.main.arn
+}
+
+# The subnets, in zone order
+output "subnet_ids" {
+  value = aws_subnet.main[*].id
+}
+
+resource "aws_vpc" "main" {}
+
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
+
+`,
+		},
+		{
+			name: "single output",
+			input: `resource "aws_vpc" "main" {}
+
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
+`,
+			expected: `resource "aws_vpc" "main" {}
+
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.SortOutputs = true
+			if formatted := New(cfg).Format([]byte(tt.input)); string(formatted) != tt.expected {
+				t.Errorf("Format() = %q, want %q", formatted, tt.expected)
+			}
+		})
+	}
+}
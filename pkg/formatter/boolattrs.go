@@ -0,0 +1,75 @@
+package formatter
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// normalizeBoolAttrs rewrites empty "name {}" blocks whose type is in
+// names as "name = true" attributes.
+//
+// This is intentionally conservative: an empty block and a true attribute
+// only mean the same thing for some provider schemas, so only the names
+// explicitly allowed are touched, and only when the block has no labels,
+// nothing but whitespace between its braces, and no other attribute or
+// block of the same name beside it. Top-level blocks are never rewritten.
+func normalizeBoolAttrs(names []string) func([]byte) []byte {
+	return func(in []byte) []byte {
+		file, diags := hclsyntax.ParseConfig(in, "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return in
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			return in
+		}
+
+		var shorthands []*hclsyntax.Block
+		var walk func(body *hclsyntax.Body, nested bool)
+		walk = func(body *hclsyntax.Body, nested bool) {
+			for _, block := range body.Blocks {
+				if nested && shorthandBool(in, body, block, names) {
+					shorthands = append(shorthands, block)
+					continue
+				}
+				walk(block.Body, true)
+			}
+		}
+		walk(body, false)
+		if len(shorthands) == 0 {
+			return in
+		}
+
+		// The blocks are in source order since they don't overlap
+		var out bytes.Buffer
+		last := 0
+		for _, block := range shorthands {
+			out.Write(in[last:block.TypeRange.Start.Byte])
+			out.WriteString(block.Type + " = true")
+			last = block.CloseBraceRange.End.Byte
+		}
+		out.Write(in[last:])
+		return out.Bytes()
+	}
+}
+
+// shorthandBool reports whether block, inside body, is an empty block of an
+// allowed type that can safely become a true attribute
+func shorthandBool(src []byte, body *hclsyntax.Body, block *hclsyntax.Block, names []string) bool {
+	if !slices.Contains(names, block.Type) || len(block.Labels) > 0 {
+		return false
+	}
+	if _, exists := body.Attributes[block.Type]; exists {
+		return false
+	}
+	for _, other := range body.Blocks {
+		if other != block && other.Type == block.Type {
+			return false
+		}
+	}
+	inner := src[block.OpenBraceRange.End.Byte:block.CloseBraceRange.Start.Byte]
+	return len(bytes.TrimSpace(inner)) == 0
+}
@@ -0,0 +1,55 @@
+package formatter
+
+import (
+	"testing"
+)
+
+// TestNormalizeBoolAttrs verifies only empty blocks of allowlisted names
+// are rewritten as true attributes
+func TestNormalizeBoolAttrs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "allowlisted empty block",
+			input:    "resource \"a\" \"b\" {\n  name = \"x\"\n  enabled {}\n}\n",
+			expected: "resource \"a\" \"b\" {\n  name = \"x\"\n  enabled = true\n}\n",
+		},
+		{
+			name:     "other empty block",
+			input:    "resource \"a\" \"b\" {\n  versioning {}\n}\n",
+			expected: "resource \"a\" \"b\" {\n  versioning {}\n}\n",
+		},
+		{
+			name:     "allowlisted block with content",
+			input:    "resource \"a\" \"b\" {\n  enabled {\n    value = false\n  }\n}\n",
+			expected: "resource \"a\" \"b\" {\n  enabled {\n    value = false\n  }\n}\n",
+		},
+		{
+			name:     "allowlisted block with a comment",
+			input:    "resource \"a\" \"b\" {\n  enabled {\n    # keep\n  }\n}\n",
+			expected: "resource \"a\" \"b\" {\n  enabled {\n    # keep\n  }\n}\n",
+		},
+		{
+			name:     "repeated allowlisted block",
+			input:    "resource \"a\" \"b\" {\n  enabled {}\n  enabled {}\n}\n",
+			expected: "resource \"a\" \"b\" {\n  enabled {}\n  enabled {}\n}\n",
+		},
+		{
+			name:     "top level",
+			input:    "enabled {}\n",
+			expected: "enabled {}\n",
+		},
+	}
+
+	normalize := normalizeBoolAttrs([]string{"enabled"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(normalize([]byte(tt.input))); got != tt.expected {
+				t.Errorf("normalizeBoolAttrs() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package formatter
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// foldDescriptions returns a pass that writes the description of variable
+// blocks as an indented heredoc when it is longer than width characters.
+// The text is kept as written, though like every heredoc it then ends in
+// a newline. Descriptions with escapes or interpolations, or starting with
+// whitespace the heredoc would strip, stay quoted.
+func foldDescriptions(width int) func([]byte) []byte {
+	return func(in []byte) []byte {
+		body := parseBody(in)
+		if body == nil {
+			return in
+		}
+
+		type fold struct {
+			start, end int
+			text       string
+		}
+		var folds []fold
+		for _, block := range body.Blocks {
+			if block.Type != "variable" {
+				continue
+			}
+			attr, ok := block.Body.Attributes["description"]
+			if !ok {
+				continue
+			}
+			tmpl, ok := attr.Expr.(*hclsyntax.TemplateExpr)
+			if !ok || len(tmpl.Parts) != 1 {
+				continue
+			}
+			rng := tmpl.SrcRange
+			if in[rng.Start.Byte] != '"' {
+				continue // already a heredoc
+			}
+			text := string(in[rng.Start.Byte+1 : rng.End.Byte-1])
+			if utf8.RuneCountInString(text) <= width || !foldable(text) {
+				continue
+			}
+			folds = append(folds, fold{rng.Start.Byte, rng.End.Byte, text})
+		}
+		if len(folds) == 0 {
+			return in
+		}
+		sort.Slice(folds, func(i, j int) bool { return folds[i].start < folds[j].start })
+
+		var out bytes.Buffer
+		last := 0
+		for _, f := range folds {
+			indent := lineIndent(in, f.start)
+			out.Write(in[last:f.start])
+			out.WriteString("<<-EOT\n" + indent + "  " + f.text + "\n" + indent + "EOT")
+			last = f.end
+		}
+		out.Write(in[last:])
+		return out.Bytes()
+	}
+}
+
+// foldable reports whether the source text of a quoted string means the
+// same written in a heredoc
+func foldable(text string) bool {
+	return !strings.ContainsAny(text, `\`) &&
+		!strings.Contains(text, "$${") && !strings.Contains(text, "%%{") &&
+		strings.TrimLeft(text, " \t") == text && strings.TrimSpace(text) != "EOT"
+}
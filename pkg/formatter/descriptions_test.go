@@ -0,0 +1,86 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestFoldDescriptions verifies long variable descriptions become heredocs
+// while short ones, and those a heredoc can't hold as written, stay inline
+func TestFoldDescriptions(t *testing.T) {
+	long := "The CIDR block of the VPC, which every subnet is carved out of"
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "long description",
+			input:    "variable \"cidr\" {\n  type        = string\n  description = \"" + long + "\"\n}\n",
+			expected: "variable \"cidr\" {\n  type        = string\n  description = <<-EOT\n    " + long + "\n  EOT\n}\n",
+		},
+		{
+			name:     "short description",
+			input:    "variable \"cidr\" {\n  description = \"The VPC CIDR\"\n}\n",
+			expected: "variable \"cidr\" {\n  description = \"The VPC CIDR\"\n}\n",
+		},
+		{
+			name:     "escapes and interpolation",
+			input:    "variable \"a\" {\n  description = \"" + long + "\\n\"\n}\n\nvariable \"b\" {\n  description = \"" + long + " ${var.x}\"\n}\n\nvariable \"c\" {\n  description = \"" + long + " $${literal}\"\n}\n",
+			expected: "variable \"a\" {\n  description = \"" + long + "\\n\"\n}\n\nvariable \"b\" {\n  description = \"" + long + " ${var.x}\"\n}\n\nvariable \"c\" {\n  description = \"" + long + " $${literal}\"\n}\n",
+		},
+		{
+			name:     "leading space",
+			input:    "variable \"cidr\" {\n  description = \"  " + long + "\"\n}\n",
+			expected: "variable \"cidr\" {\n  description = \"  " + long + "\"\n}\n",
+		},
+		{
+			name:     "already a heredoc",
+			input:    "variable \"cidr\" {\n  description = <<-EOT\n    " + long + "\n  EOT\n}\n",
+			expected: "variable \"cidr\" {\n  description = <<-EOT\n    " + long + "\n  EOT\n}\n",
+		},
+		{
+			name:     "other blocks",
+			input:    "output \"cidr\" {\n  description = \"" + long + "\"\n}\n",
+			expected: "output \"cidr\" {\n  description = \"" + long + "\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := foldDescriptions(40)([]byte(tt.input))
+			if string(got) != tt.expected {
+				t.Errorf("foldDescriptions() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFoldDescriptionsText verifies a folded description holds the same
+// text and that formatting it again changes nothing
+func TestFoldDescriptionsText(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.FoldDescriptions = 20
+	formatter := New(cfg)
+
+	text := "Tags for every resource, like {team = infra}, 100% owned"
+	input := "variable \"tags\" {\n  description = \"" + text + "\"\n  type = map(string)\n}\n"
+	formatted := formatter.Format([]byte(input))
+	if again := formatter.Format(formatted); string(again) != string(formatted) {
+		t.Errorf("Format() isn't stable: %q, then %q", formatted, again)
+	}
+
+	file, diags := hclsyntax.ParseConfig(formatted, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("Format() = %q: %v", formatted, diags)
+	}
+	attrs, _ := file.Body.(*hclsyntax.Body).Blocks[0].Body.JustAttributes()
+	value, _ := attrs["description"].Expr.Value(nil)
+	if got := strings.TrimSuffix(value.AsString(), "\n"); got != text {
+		t.Errorf("folded description = %q, want %q", got, text)
+	}
+}
@@ -0,0 +1,97 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// DumpAST writes the hclwrite block and attribute structure of a terraform
+// file to w, one line per node in source order, with labels quoted and
+// nested nodes indented. It is a debugging aid for understanding which
+// blocks and attributes the formatting passes see.
+func DumpAST(w io.Writer, content []byte, filename string) error {
+	file, diags := hclwrite.ParseConfig(content, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	// hclwrite tokens don't record where they are, but the tree shares them
+	// with the token list of the whole file, so they can be looked up there
+	positions := map[*hclwrite.Token]tokenPos{}
+	line := 1
+	for i, tok := range file.BuildTokens(nil) {
+		positions[tok] = tokenPos{index: i, line: line}
+		line += bytes.Count(tok.Bytes, []byte("\n"))
+	}
+	return dumpBody(w, file.Body(), positions, 0)
+}
+
+// tokenPos is where a token is in the token list of a file
+type tokenPos struct {
+	index int
+	line  int
+}
+
+// nodePos returns the position of the first token of a node that isn't a
+// comment leading it
+func nodePos(tokens hclwrite.Tokens, positions map[*hclwrite.Token]tokenPos) tokenPos {
+	for _, tok := range tokens {
+		if tok.Type != hclsyntax.TokenComment && tok.Type != hclsyntax.TokenNewline {
+			return positions[tok]
+		}
+	}
+	return tokenPos{}
+}
+
+// dumpBody writes the attributes and blocks of body at the given depth
+func dumpBody(w io.Writer, body *hclwrite.Body, positions map[*hclwrite.Token]tokenPos, depth int) error {
+	type node struct {
+		pos   tokenPos
+		text  string
+		block *hclwrite.Block
+	}
+
+	attrs := body.Attributes()
+	blocks := body.Blocks()
+	nodes := make([]node, 0, len(attrs)+len(blocks))
+	for name, attr := range attrs {
+		nodes = append(nodes, node{
+			pos:  nodePos(attr.BuildTokens(nil), positions),
+			text: "attribute " + name,
+		})
+	}
+	for _, block := range blocks {
+		text := "block " + block.Type()
+		for _, label := range block.Labels() {
+			text += fmt.Sprintf(" %q", label)
+		}
+		nodes = append(nodes, node{
+			pos:   nodePos(block.BuildTokens(nil), positions),
+			text:  text,
+			block: block,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].pos.index < nodes[j].pos.index
+	})
+
+	indent := strings.Repeat("  ", depth)
+	for _, n := range nodes {
+		if _, err := fmt.Fprintf(w, "%s%s (line %d)\n", indent, n.text, n.pos.line); err != nil {
+			return err
+		}
+		if n.block != nil {
+			if err := dumpBody(w, n.block.Body(), positions, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestDumpAST verifies the dump lists blocks, labels and attributes in source order,
+// at the line of their name rather than that of a comment leading them
+func TestDumpAST(t *testing.T) {
+	input := `provider "aws" {
+  region = "us-west-2"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t2.micro"
+  # The image is pinned
+  ami           = "ami-12345"
+
+  lifecycle {
+    create_before_destroy = true
+  }
+  timeouts { create = "5m" }
+}
+`
+	expected := `block provider "aws" (line 1)
+  attribute region (line 2)
+block resource "aws_instance" "web" (line 5)
+  attribute instance_type (line 6)
+  attribute ami (line 8)
+  block lifecycle (line 10)
+    attribute create_before_destroy (line 11)
+  block timeouts (line 13)
+    attribute create (line 13)
+`
+
+	var out bytes.Buffer
+	if err := DumpAST(&out, []byte(input), "main.tf"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("DumpAST() =\n%s\nwant:\n%s", out.String(), expected)
+	}
+
+	if err := DumpAST(&out, []byte("resource {"), "broken.tf"); err == nil {
+		t.Errorf("DumpAST() with invalid input should return an error")
+	}
+}
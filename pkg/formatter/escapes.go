@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// normalizeEscapes writes \uNNNN and \UNNNNNNNN escapes in quoted strings
+// as the character they stand for, when that character means the same
+// written out. Quotes, backslashes, "$", "%" and braces stay escaped so no
+// interpolation or directive can appear, as do control characters, spaces
+// other than " " and combining marks, which are invisible or change the
+// look of their neighbours. Heredocs don't process escapes and are left
+// alone.
+func normalizeEscapes(in []byte) []byte {
+	return rewriteTokens(in, func(tok hclsyntax.Token) ([]byte, bool) {
+		if tok.Type != hclsyntax.TokenQuotedLit || !bytes.Contains(tok.Bytes, []byte(`\`)) {
+			return nil, false
+		}
+		return unescapeLiteral(tok.Bytes)
+	})
+}
+
+// unescapeLiteral rewrites the unnecessary unicode escapes of a quoted
+// string literal, reporting whether any was found
+func unescapeLiteral(lit []byte) ([]byte, bool) {
+	var out bytes.Buffer
+	changed := false
+	for i := 0; i < len(lit); i++ {
+		if lit[i] != '\\' || i+1 == len(lit) {
+			out.WriteByte(lit[i])
+			continue
+		}
+
+		digits := map[byte]int{'u': 4, 'U': 8}[lit[i+1]]
+		if digits == 0 || i+2+digits > len(lit) {
+			// Copy other escapes whole, so \\u0041 isn't read as A
+			out.Write(lit[i : i+2])
+			i++
+			continue
+		}
+		escape := lit[i : i+2+digits]
+		code, err := strconv.ParseUint(string(escape[2:]), 16, 32)
+		if r := rune(code); err == nil && plainRune(r) {
+			out.WriteRune(r)
+			changed = true
+		} else {
+			out.Write(escape)
+		}
+		i += len(escape) - 1
+	}
+	return out.Bytes(), changed
+}
+
+// plainRune reports whether r can be written in a quoted string as itself
+// without changing what the string means or looks like
+func plainRune(r rune) bool {
+	if !utf8.ValidRune(r) || strings.ContainsRune(`"\$%{}`, r) {
+		return false
+	}
+	return r == ' ' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
@@ -0,0 +1,84 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// TestNormalizeEscapes verifies unnecessary unicode escapes are written as
+// their characters while everything that needs escaping stays escaped
+func TestNormalizeEscapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "letters and punctuation",
+			input:    `name = "ABC-\U0001F600"` + "\n",
+			expected: `name = "ABC-😀"` + "\n",
+		},
+		{
+			name:     "non-ascii letter",
+			input:    `name = "caf\u00e9"` + "\n",
+			expected: `name = "café"` + "\n",
+		},
+		{
+			name:     "escapes that are needed",
+			input:    `name = "\u0022\u005C\u0024{x}\u0025{if}\u007B\u0009\u000a\u00a0e\u0301"` + "\n",
+			expected: `name = "\u0022\u005C\u0024{x}\u0025{if}\u007B\u0009\u000a\u00a0e\u0301"` + "\n",
+		},
+		{
+			name:     "escaped backslash before u",
+			input:    `name = "\\u0041 \n \"  "` + "\n",
+			expected: `name = "\\u0041 \n \"  "` + "\n",
+		},
+		{
+			name:     "inside an interpolation",
+			input:    `name = "${upper("\u0061")}\u0041"` + "\n",
+			expected: `name = "${upper("a")}A"` + "\n",
+		},
+		{
+			name:     "heredoc",
+			input:    "name = <<EOT\n\\u0041\nEOT\n",
+			expected: "name = <<EOT\n\\u0041\nEOT\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeEscapes([]byte(tt.input))
+			if string(got) != tt.expected {
+				t.Errorf("normalizeEscapes() = %q, want %q", got, tt.expected)
+			}
+			if before, after := literalValue(t, []byte(tt.input)), literalValue(t, got); !before.RawEquals(after) {
+				t.Errorf("value changed from %#v to %#v", before, after)
+			}
+		})
+	}
+}
+
+// literalValue evaluates the name attribute of src the way Terraform
+// would, with upper as the only function
+func literalValue(t *testing.T, src []byte) cty.Value {
+	t.Helper()
+	file, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("%s does not parse: %s", src, diags)
+	}
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	ctx := &hcl.EvalContext{Functions: map[string]function.Function{"upper": stdlib.UpperFunc}}
+	value, diags := attrs["name"].Expr.Value(ctx)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	return value
+}
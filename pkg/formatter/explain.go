@@ -0,0 +1,223 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Explain formats content like FormatStats and returns, in the order they
+// were applied, a human readable reason for every change made
+func (f *Formatter) Explain(content []byte) []string {
+	var reasons []string
+	run := func(chunk []byte, fragment bool) []byte {
+		in := chunk
+		for _, p := range f.passes(chunk, fragment) {
+			if f.Config.SkipUnsafePasses {
+				if reason := skipReason(p.name, in); reason != "" {
+					reasons = append(reasons, Warning{p.name, reason}.String())
+					continue
+				}
+			}
+			out := p.run(in)
+			if !bytes.Equal(in, out) {
+				reasons = append(reasons, f.reasons(p.name, in, out)...)
+			}
+			in = out
+		}
+		return in
+	}
+
+	if f.Config.LabelMatch != "" {
+		f.formatMatching(content, func(chunk []byte) []byte {
+			return run(chunk, true)
+		})
+	} else {
+		run(content, f.Config.Fragment)
+	}
+	return reasons
+}
+
+// reasons describes what the pass called name changed when it turned in
+// into out
+func (f *Formatter) reasons(name string, in, out []byte) []string {
+	switch name {
+	case "modernize":
+		return []string{"rewrote list() and map() calls as literals"}
+	case "preprocess":
+		return []string{fmt.Sprintf(`split %d "({" or "})" onto separate lines`, addedLines(in, out))}
+	case "sort_inputs":
+		n := movedAttributes(in, out, "resource")
+		if n == 0 {
+			return []string{"rewrote the attributes of resource blocks, already in order"}
+		}
+		return []string{fmt.Sprintf("sorted %s of resource blocks", plural(n, "attribute", "attributes"))}
+	case "sort_vars":
+		n := moved(blockLabels(in, "variable"), blockLabels(out, "variable"))
+		return []string{fmt.Sprintf("sorted %s", plural(n, "variable block", "variable blocks"))}
+	case "sort_data":
+		n := moved(blockLabels(in, "data"), blockLabels(out, "data"))
+		return []string{fmt.Sprintf("sorted %s", plural(n, "data block", "data blocks"))}
+	case "sort_outputs":
+		n := moved(blockLabels(in, "output"), blockLabels(out, "output"))
+		return []string{fmt.Sprintf("sorted %s", plural(n, "output block", "output blocks"))}
+	case "merge_locals":
+		n := len(blockLabels(in, "locals"))
+		return []string{fmt.Sprintf("merged %d locals blocks into one", n)}
+	case "sort_locals":
+		n := movedAttributes(in, out, "locals")
+		if n == 0 {
+			return []string{"rewrote locals, already in order"}
+		}
+		return []string{fmt.Sprintf("sorted %s", plural(n, "local", "locals"))}
+	case "bool_attrs":
+		return []string{"turned empty blocks into boolean attributes"}
+	case "canonical_dynamic":
+		return []string{"moved the meta-arguments of dynamic blocks before their content"}
+	case "canonical_lifecycle":
+		return []string{"put the arguments of lifecycle blocks in their conventional order"}
+	case "comment_style":
+		return []string{fmt.Sprintf("rewrote line comments in the %s style", f.Config.CommentStyle)}
+	case "hcl_format":
+		return []string{"applied canonical HCL formatting"}
+	case "for_spacing":
+		return []string{"normalized spacing in for expressions"}
+	case "list_spacing":
+		return []string{"normalized spacing in single-line lists"}
+	case "wrap_calls":
+		return []string{fmt.Sprintf("wrapped calls wider than %d columns", f.Config.WrapCalls)}
+	case "heredoc_indent":
+		return []string{"fixed the indentation of heredocs"}
+	case "eol_in_strings":
+		return []string{"normalized line endings in strings"}
+	case "escapes":
+		return []string{"wrote unnecessary unicode escapes in strings as the characters themselves"}
+	case "align_scope":
+		return []string{"aligned attributes across whole blocks"}
+	case "trailing_comments":
+		return []string{"aligned trailing comments across whole blocks"}
+	case "provider_versions":
+		return []string{"normalized the spacing of provider version constraints"}
+	case "fold_descriptions":
+		n := bytes.Count(out, []byte("<<-EOT")) - bytes.Count(in, []byte("<<-EOT"))
+		return []string{fmt.Sprintf("folded %s into heredocs", plural(n, "long description", "long descriptions"))}
+	case "indent":
+		return []string{fmt.Sprintf("indented with %d spaces per level", f.Config.Indent)}
+	case "blank_lines":
+		// Mirror the pass to tell collapsing apart from padding
+		collapsed := f.collapseBlankLines(in)
+		var reasons []string
+		if n := addedLines(collapsed, in); n > 0 {
+			reasons = append(reasons, fmt.Sprintf("collapsed %s", plural(n, "extra blank line", "extra blank lines")))
+		}
+		if n := addedLines(collapsed, out); n > 0 {
+			reasons = append(reasons, fmt.Sprintf("added %s between blocks", plural(n, "blank line", "blank lines")))
+		} else if n < 0 {
+			reasons = append(reasons, fmt.Sprintf("removed %s between blocks", plural(-n, "blank line", "blank lines")))
+		}
+		return reasons
+	case "resource_spacing":
+		if f.singleSpaced() {
+			return []string{fmt.Sprintf("added %s between resource blocks", plural(addedLines(in, out), "line break", "line breaks"))}
+		}
+		return []string{fmt.Sprintf("added %s between resource blocks", plural(addedLines(in, out), "blank line", "blank lines"))}
+	case "trailing_newlines":
+		return []string{"normalized the newlines ending the file"}
+	case "preserve_attrs":
+		return []string{"restored preserved attributes"}
+	}
+	return []string{"applied " + name}
+}
+
+// addedLines returns how many more lines b has than a
+func addedLines(a, b []byte) int {
+	return bytes.Count(b, []byte("\n")) - bytes.Count(a, []byte("\n"))
+}
+
+// plural formats n with the singular or plural noun for it
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// moved counts the positions holding a different entry in a and b
+func moved(a, b []string) int {
+	n := 0
+	for i := range min(len(a), len(b)) {
+		if a[i] != b[i] {
+			n++
+		}
+	}
+	return n
+}
+
+// movedAttributes counts the attributes of top-level blocks of blockType
+// that are in a different position in out than in in
+func movedAttributes(in, out []byte, blockType string) int {
+	before, after := attributeNames(in, blockType), attributeNames(out, blockType)
+	n := 0
+	for i := range min(len(before), len(after)) {
+		n += moved(before[i], after[i])
+	}
+	return n
+}
+
+// attributeNames returns the attribute names of each top-level block of
+// blockType, in source order
+func attributeNames(src []byte, blockType string) [][]string {
+	body := parseBody(src)
+	if body == nil {
+		return nil
+	}
+	var names [][]string
+	for _, block := range body.Blocks {
+		if block.Type != blockType {
+			continue
+		}
+		attrs := make([]*hclsyntax.Attribute, 0, len(block.Body.Attributes))
+		for _, attr := range block.Body.Attributes {
+			attrs = append(attrs, attr)
+		}
+		sort.Slice(attrs, func(i, j int) bool {
+			return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+		})
+		blockNames := make([]string, len(attrs))
+		for i, attr := range attrs {
+			blockNames[i] = attr.Name
+		}
+		names = append(names, blockNames)
+	}
+	return names
+}
+
+// blockLabels returns the labels of each top-level block of blockType
+// joined by dots, in source order
+func blockLabels(src []byte, blockType string) []string {
+	body := parseBody(src)
+	if body == nil {
+		return nil
+	}
+	var labels []string
+	for _, block := range body.Blocks {
+		if block.Type == blockType {
+			labels = append(labels, strings.Join(block.Labels, "."))
+		}
+	}
+	return labels
+}
+
+// parseBody parses src, returning nil when it isn't valid HCL
+func parseBody(src []byte) *hclsyntax.Body {
+	file, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	body, _ := file.Body.(*hclsyntax.Body)
+	return body
+}
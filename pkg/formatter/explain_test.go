@@ -0,0 +1,64 @@
+package formatter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestExplain verifies the explanation lists the rules that fired, and
+// only those
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name         string
+		sortInputs   bool
+		blockSpacing int
+		input        string
+		expected     []string
+	}{
+		{
+			name:     "formatted",
+			input:    "resource \"a\" \"b\" {\n  foo = bar\n}\n\n",
+			expected: nil,
+		},
+		{
+			name:       "sorting, splitting and spacing",
+			sortInputs: true,
+			input:      "resource \"a\" \"b\" {\n  zone = 1\n  name = 2\n  args = merge({\n    a = 1\n  })\n}\n\n\n\n\nresource \"a\" \"c\" {\n  foo = bar\n}\n\n",
+			expected: []string{
+				`split 2 "({" or "})" onto separate lines`,
+				"sorted 2 attributes of resource blocks",
+				"applied canonical HCL formatting",
+				"collapsed 3 extra blank lines",
+				"added 1 blank line between blocks",
+			},
+		},
+		{
+			name:     "blank line between blocks",
+			input:    "locals {\n  a = 1\n}\nlocals {\n  b = 2\n}\n\n",
+			expected: []string{"added 1 blank line between blocks"},
+		},
+		{
+			name:         "single block spacing",
+			blockSpacing: 1,
+			input:        "locals {\n  a = 1\n}\n\nresource \"a\" \"b\" {}\nresource \"a\" \"c\" {}\n\n",
+			expected:     []string{"removed 1 blank line between blocks"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.SortInputs = tt.sortInputs
+			if tt.blockSpacing > 0 {
+				cfg.BlockSpacing = tt.blockSpacing
+			}
+			f := New(cfg)
+
+			if reasons := f.Explain([]byte(tt.input)); !reflect.DeepEqual(reasons, tt.expected) {
+				t.Errorf("Explain() = %q, want %q", reasons, tt.expected)
+			}
+		})
+	}
+}
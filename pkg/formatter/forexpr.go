@@ -0,0 +1,100 @@
+package formatter
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// forScope tracks a bracket that may hold a for expression
+type forScope struct {
+	isFor   bool // the bracket opens with the for keyword
+	inSeen  bool // the in keyword was passed
+	body    bool // the colon after the collection was passed
+	pending int  // conditional "?" still waiting for their ":"
+}
+
+// normalizeForSpacing puts exactly one space on both sides of the ":" and
+// the "if" keyword of for expressions. Colons of conditionals and of
+// objects nested in a for expression are left alone, as is spacing that
+// spans lines.
+func normalizeForSpacing(in []byte) []byte {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+
+	// Indexes of the tokens that need a single space on both sides
+	var targets []int
+	var stack []forScope
+	for i, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenOBrack, hclsyntax.TokenOBrace:
+			isFor := i+1 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenIdent && string(tokens[i+1].Bytes) == "for"
+			stack = append(stack, forScope{isFor: isFor})
+			continue
+		case hclsyntax.TokenCBrack, hclsyntax.TokenCBrace:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		case hclsyntax.TokenOParen, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			stack = append(stack, forScope{})
+			continue
+		case hclsyntax.TokenCParen, hclsyntax.TokenTemplateSeqEnd:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		if len(stack) == 0 || !stack[len(stack)-1].isFor {
+			continue
+		}
+
+		scope := &stack[len(stack)-1]
+		switch {
+		case tok.Type == hclsyntax.TokenIdent && string(tok.Bytes) == "in" && !scope.inSeen:
+			scope.inSeen = true
+		case tok.Type == hclsyntax.TokenQuestion && scope.inSeen:
+			scope.pending++
+		case tok.Type == hclsyntax.TokenColon && scope.inSeen:
+			if scope.pending > 0 {
+				scope.pending--
+			} else if !scope.body {
+				scope.body = true
+				targets = append(targets, i)
+			}
+		case tok.Type == hclsyntax.TokenIdent && string(tok.Bytes) == "if" && scope.body &&
+			tokens[i-1].Type != hclsyntax.TokenDot:
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 {
+		return in
+	}
+
+	var out bytes.Buffer
+	last := 0
+	space := func(from, to int) {
+		gap := in[from:to]
+		if len(bytes.Trim(gap, " \t")) == 0 {
+			out.WriteByte(' ')
+		} else {
+			out.Write(gap)
+		}
+	}
+	for _, i := range targets {
+		before, tok, after := tokens[i-1], tokens[i], tokens[i+1]
+		if before.Range.End.Byte < last {
+			continue
+		}
+		out.Write(in[last:before.Range.End.Byte])
+		space(before.Range.End.Byte, tok.Range.Start.Byte)
+		out.Write(tok.Bytes)
+		space(tok.Range.End.Byte, after.Range.Start.Byte)
+		last = after.Range.Start.Byte
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
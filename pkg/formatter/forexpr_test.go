@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestForExpressionSpacing verifies for expressions get canonical spacing
+// around ":" and "if" without touching object colons
+func TestForExpressionSpacing(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "tight for expression",
+			input:    "names = [for x in var.list:x if(x != \"\")]\n",
+			expected: "names = [for x in var.list : x if (x != \"\")]\n\n",
+		},
+		{
+			name:     "conditional collection",
+			input:    "names = {for k, v in var.on?var.a:var.b:k=>v if(v)}\n",
+			expected: "names = { for k, v in var.on ? var.a : var.b : k => v if (v) }\n\n",
+		},
+		{
+			name:     "object colons",
+			input:    "obj = {a: 1}\n",
+			expected: "obj = { a : 1 }\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := New(config.NewConfig())
+			if out := string(formatter.Format([]byte(tt.input))); out != tt.expected {
+				t.Errorf("Format(%q) = %q, want %q", tt.input, out, tt.expected)
+			}
+		})
+	}
+}
@@ -4,10 +4,13 @@ package formatter
 
 import (
 	"bytes"
+	"io"
 	"regexp"
+	"slices"
 	"sort"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/krewenki/tffmt/pkg/config"
 )
@@ -19,6 +22,9 @@ var (
 	reCollapseBlank   = regexp.MustCompile(`\n{3,}`)                // ≥3 ⇒ 2
 	rePadSingle       = regexp.MustCompile(`}\n([^\n])`)            // 1 ⇒ 2
 	reResourceBlocks  = regexp.MustCompile(`}\n{0,2}(resource\s+)`) // Ensure exactly 2 newlines between resource blocks
+
+	// Ensure exactly 1 newline between resource blocks under a block spacing of 1
+	reResourceBlocksSingle = regexp.MustCompile(`}\n?(resource\s+)`)
 )
 
 // Formatter holds configuration for the formatting process
@@ -33,141 +39,540 @@ func New(cfg *config.Config) *Formatter {
 	}
 }
 
+// Stats counts, for each formatting rule, the number of files in which
+// the rule changed the content
+type Stats map[string]int
+
+// Add adds the counts in other to s
+func (s Stats) Add(other Stats) {
+	for rule, n := range other {
+		s[rule] += n
+	}
+}
+
+// pass is a single named formatting rule
+type pass struct {
+	name string
+	run  func([]byte) []byte
+}
+
+// unsafeInputs holds, by rule, a func returning why the rule can't be
+// trusted with its input, or "" when it can
+var unsafeInputs = map[string]func([]byte) string{
+	"heredoc_indent": nestedHeredoc,
+}
+
+// Warning reports a rule that was skipped for a file it couldn't safely
+// format
+type Warning struct {
+	Pass   string
+	Reason string
+}
+
+func (w Warning) String() string {
+	return w.Pass + " skipped: " + w.Reason
+}
+
+// runPasses applies each pass in order, counting the passes that changed
+// the content in stats when it is non-nil. When warnings is non-nil, rules
+// that can't be trusted with their input are skipped and recorded in it.
+func runPasses(passes []pass, in []byte, stats Stats, warnings *[]Warning) []byte {
+	for _, p := range passes {
+		if warnings != nil {
+			if reason := skipReason(p.name, in); reason != "" {
+				*warnings = append(*warnings, Warning{p.name, reason})
+				continue
+			}
+		}
+		out := p.run(in)
+		if stats != nil {
+			n := stats[p.name]
+			if !bytes.Equal(in, out) {
+				n++
+			}
+			stats[p.name] = n
+		}
+		in = out
+	}
+	return in
+}
+
+// skipReason returns why the rule called name must not run on in, or ""
+// when it may
+func skipReason(name string, in []byte) string {
+	if unsafe, ok := unsafeInputs[name]; ok {
+		return unsafe(in)
+	}
+	return ""
+}
+
 // Format processes a single terraform file and returns the formatted content
 func (f *Formatter) Format(content []byte) []byte {
-	// 1. custom pre-split
-	src := f.Preprocess(content)
+	form, _ := f.FormatStats(content)
+	return form
+}
 
-	// 2. canonical hcl formatting
-	form := hclwrite.Format(src)
+// FormatStats formats a terraform file like Format, and also reports which
+// formatting rules changed the content
+func (f *Formatter) FormatStats(content []byte) ([]byte, Stats) {
+	formatted, stats, _ := f.FormatWarnings(content)
+	return formatted, stats
+}
 
-	// 3. 2 blank lines between top-level blocks
-	form = reCollapseBlank.ReplaceAll(form, []byte("\n\n"))
-	form = rePadSingle.ReplaceAll(form, []byte("}\n\n$1"))
+// FormatWarnings formats a terraform file like FormatStats, and also
+// reports the rules skipped because they couldn't safely format it
+func (f *Formatter) FormatWarnings(content []byte) ([]byte, Stats, []Warning) {
+	stats := Stats{}
+	var warnings []Warning
+	record := &warnings
+	if !f.Config.SkipUnsafePasses {
+		record = nil
+	}
+	if f.Config.LabelMatch != "" {
+		// Count a rule once if it changed any of the blocks
+		formatted := f.formatMatching(content, func(chunk []byte) []byte {
+			chunkStats := Stats{}
+			out := runPasses(f.passes(chunk, true), chunk, chunkStats, record)
+			for rule, n := range chunkStats {
+				stats[rule] = min(stats[rule]+n, 1)
+			}
+			return out
+		})
+		return formatted, stats, warnings
+	}
+	formatted := runPasses(f.passes(content, f.Config.Fragment), content, stats, record)
+	return formatted, stats, warnings
+}
 
-	// Ensure exactly two newlines between resource blocks
-	form = reResourceBlocks.ReplaceAll(form, []byte("}\n\n$1"))
+// passes returns every rule applied when formatting content, which keeps
+// its own trailing newlines when it is a fragment
+func (f *Formatter) passes(content []byte, fragment bool) []pass {
+	passes := append(f.prePasses(), f.postPasses()...)
 
-	// 4. ensure exactly two trailing newlines
-	form = bytes.TrimRight(form, "\n")
-	form = append(form, '\n', '\n')
+	// Fragments keep their own trailing newlines instead of the usual two
+	if fragment {
+		for i := range passes {
+			if passes[i].name == "trailing_newlines" {
+				passes[i] = fragmentEnding(content)
+			}
+		}
+	}
 
-	return form
+	// Restore the values of preserved attributes last, so no other rule
+	// can touch them
+	if len(f.Config.PreserveAttrs) > 0 {
+		preserved := capturePreserved(content, f.Config.PreserveAttrs)
+		passes = append(passes, pass{"preserve_attrs", func(in []byte) []byte {
+			return restorePreserved(in, preserved)
+		}})
+	}
+	return passes
+}
+
+// ParenSplitOnly reports whether splitting "({" and "})" onto separate
+// lines is the only reason Format changes content
+func (f *Formatter) ParenSplitOnly(content []byte) bool {
+	var passes []pass
+	for _, p := range f.passes(content, f.Config.Fragment) {
+		if p.name != "preprocess" {
+			passes = append(passes, p)
+		}
+	}
+	return bytes.Equal(runPasses(passes, content, nil, nil), content) && !bytes.Equal(f.Format(content), content)
 }
 
 // Preprocess performs initial transformations on terraform content
 // such as splitting "({" and "})" into separate lines
 func (f *Formatter) Preprocess(in []byte) []byte {
-	out := reOpenParenBrace.ReplaceAll(in, []byte("(\n{"))
-	out = reCloseBraceParen.ReplaceAll(out, []byte("}\n)"))
+	return runPasses(f.prePasses(), in, nil, nil)
+}
+
+// prePasses returns the rules applied before canonical hcl formatting
+func (f *Formatter) prePasses() []pass {
+	var passes []pass
+
+	// Rewrite list() and map() first, so the objects they turn into get
+	// the same treatment as any other
+	if f.Config.Modernize {
+		passes = append(passes, pass{"modernize", modernize})
+	}
+
+	// custom pre-split
+	passes = append(passes, pass{"preprocess", func(in []byte) []byte {
+		out := replaceLiteralSafe(reOpenParenBrace, in, []byte("(\n{"))
+		return replaceLiteralSafe(reCloseBraceParen, out, []byte("}\n)"))
+	}})
 
 	// Apply additional transformations if SortInputs is enabled
 	if f.Config.SortInputs {
-		out = f.sortResourceInputs(out)
+		passes = append(passes, pass{"sort_inputs", f.sortResourceInputs})
 	}
 
 	// Apply variable sorting if SortVars is enabled
 	if f.Config.SortVars {
-		out = f.sortVariableBlocks(out)
+		passes = append(passes, pass{"sort_vars", f.sortVariableBlocks})
+	}
+
+	if f.Config.SortData {
+		passes = append(passes, pass{"sort_data", f.sortDataBlocks})
+	}
+
+	if f.Config.SortOutputs {
+		passes = append(passes, pass{"sort_outputs", f.sortOutputBlocks})
+	}
+
+	// Consolidate locals into a single block, then sort them
+	if f.Config.MergeLocals && f.reorderable("locals") {
+		passes = append(passes, pass{"merge_locals", mergeLocals})
+	}
+	if f.Config.SortLocals && f.reorderable("locals") {
+		passes = append(passes, pass{"sort_locals", func(in []byte) []byte {
+			return sortLocals(in, f.nameLess())
+		}})
+	}
+
+	// Turn allowlisted empty blocks into true attributes
+	if len(f.Config.NormalizeBoolAttrs) > 0 {
+		passes = append(passes, pass{"bool_attrs", normalizeBoolAttrs(f.Config.NormalizeBoolAttrs)})
+	}
+
+	// Put the meta-arguments of dynamic blocks before their content
+	if f.Config.CanonicalDynamic {
+		passes = append(passes, pass{"canonical_dynamic", func(in []byte) []byte {
+			return reorderBodies(in, f.itemOrder(dynamicOrder), f.Config.NoReorderTypes)
+		}})
+	}
+
+	// Put the arguments of lifecycle blocks in their conventional order
+	if f.Config.CanonicalLifecycle {
+		passes = append(passes, pass{"canonical_lifecycle", func(in []byte) []byte {
+			return reorderBodies(in, f.itemOrder(lifecycleOrder), f.Config.NoReorderTypes)
+		}})
 	}
 
-	return out
+	// Normalize line comments to a single style
+	return append(passes, pass{"comment_style", func(in []byte) []byte {
+		return normalizeComments(in, f.Config.CommentStyle)
+	}})
 }
 
-// sortResourceInputs alphabetically sorts the inputs within resource blocks
-func (f *Formatter) sortResourceInputs(in []byte) []byte {
-	// Parse the HCL content
-	file, err := hclwrite.ParseConfig(in, "", hcl.InitialPos)
-	if err != nil {
-		// If there's an error parsing, return the original content unchanged
-		return in
+// postPasses returns canonical hcl formatting and the rules applied after it
+func (f *Formatter) postPasses() []pass {
+	passes := []pass{
+		{"hcl_format", hclwrite.Format},
+		{"for_spacing", normalizeForSpacing},
+	}
+
+	if f.Config.NormalizeListSpacing {
+		passes = append(passes, pass{"list_spacing", normalizeListSpacing})
+	}
+
+	if f.Config.NormalizeProviderVersions {
+		passes = append(passes, pass{"provider_versions", normalizeProviderVersions})
+	}
+
+	if f.Config.WrapCalls > 0 {
+		passes = append(passes, pass{"wrap_calls", wrapCalls(f.Config.WrapCalls)})
+	}
+
+	if f.Config.FoldDescriptions > 0 {
+		passes = append(passes, pass{"fold_descriptions", foldDescriptions(f.Config.FoldDescriptions)})
+	}
+
+	if f.Config.FixHeredocIndent {
+		passes = append(passes, pass{"heredoc_indent", fixHeredocIndent})
+	}
+
+	if f.Config.NormalizeEOLInStrings {
+		passes = append(passes, pass{"eol_in_strings", normalizeLiteralEOL})
+	}
+
+	if f.Config.NormalizeEscapes {
+		passes = append(passes, pass{"escapes", normalizeEscapes})
+	}
+
+	if f.Config.AlignScope == "block" {
+		passes = append(passes, pass{"align_scope", alignBlockScope})
+	}
+
+	if f.Config.AlignTrailingComments {
+		passes = append(passes, pass{"trailing_comments", alignTrailingComments})
+	}
+
+	if f.Config.Indent > 0 && f.Config.Indent != 2 {
+		passes = append(passes, pass{"indent", reindent(f.Config.Indent)})
 	}
 
-	// Process all top level blocks
-	for _, block := range file.Body().Blocks() {
-		// We're looking for resource blocks
-		if block.Type() == "resource" {
-			// Get the attribute names in the block body
-			attributes := block.Body().Attributes()
-			if len(attributes) > 0 {
-				// Get all attribute names
-				attrNames := make([]string, 0, len(attributes))
-				for name := range attributes {
-					attrNames = append(attrNames, name)
-				}
-
-				// Sort the attribute names
-				sort.Strings(attrNames)
-
-				// Create a temporary map to hold all attributes
-				attrMap := make(map[string]*hclwrite.Attribute)
-				for name, attr := range attributes {
-					attrMap[name] = attr
-				}
-
-				// Remove all attributes from the block
-				for name := range attributes {
-					block.Body().RemoveAttribute(name)
-				}
-
-				// Add them back in sorted order
-				for _, name := range attrNames {
-					expr := attrMap[name].Expr().BuildTokens(nil)
-					block.Body().SetAttributeRaw(name, expr)
-				}
+	return append(passes,
+		// 2 blank lines between top-level blocks
+		pass{"blank_lines", func(in []byte) []byte {
+			out := f.collapseBlankLines(in)
+			if f.singleSpaced() {
+				return joinBlocks(out)
 			}
+			return replaceLiteralSafe(rePadSingle, out, []byte("}\n\n$1"))
+		}},
+		// Ensure exactly two newlines between resource blocks
+		pass{"resource_spacing", func(in []byte) []byte {
+			if f.singleSpaced() {
+				return replaceLiteralSafe(reResourceBlocksSingle, in, []byte("}\n$1"))
+			}
+			return replaceLiteralSafe(reResourceBlocks, in, []byte("}\n\n$1"))
+		}},
+		// ensure exactly two trailing newlines
+		pass{"trailing_newlines", func(in []byte) []byte {
+			out := bytes.TrimRight(in, "\n")
+			return append(out, '\n', '\n')
+		}},
+	)
+}
+
+// collapseBlankLines collapses runs of blank lines into one, except
+// between the lines of a comment under -preserve-blank-lines-in-comments
+func (f *Formatter) collapseBlankLines(in []byte) []byte {
+	var keep []span
+	if f.Config.PreserveBlankLinesInComments {
+		keep = commentGaps(in)
+	}
+	return replaceLiteralSafe(reCollapseBlank, in, []byte("\n\n"), keep...)
+}
+
+// joinBlocks removes the blank lines between consecutive top-level blocks,
+// for a block spacing of 1. Blank lines inside blocks separate alignment
+// groups and stay, as do those next to top-level attributes.
+func joinBlocks(in []byte) []byte {
+	items, ok := topLevelItems(in)
+	if !ok {
+		return in
+	}
+	var out bytes.Buffer
+	last := 0
+	for i := 1; i < len(items); i++ {
+		prev, next := items[i-1], items[i]
+		if prev.block == nil || next.block == nil {
+			continue
+		}
+		gapStart := prev.end
+		if nl := bytes.IndexByte(in[gapStart:next.start], '\n'); nl >= 0 {
+			gapStart += nl + 1
+		}
+		gapEnd := bytes.LastIndexByte(in[:next.start], '\n') + 1
+		if gapEnd <= gapStart {
+			continue
 		}
+		out.Write(in[last:gapStart])
+		for _, line := range bytes.SplitAfter(in[gapStart:gapEnd], []byte("\n")) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				out.Write(line)
+			}
+		}
+		last = gapEnd
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
+
+// topLevelItems returns the top-level attributes and blocks of src in
+// source order, each spanning its own source range. It reports false when
+// src doesn't parse.
+func topLevelItems(src []byte) ([]bodyItem, bool) {
+	file, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+	items := make([]bodyItem, 0, len(body.Attributes)+len(body.Blocks))
+	for name, attr := range body.Attributes {
+		items = append(items, bodyItem{name: name, start: attr.SrcRange.Start.Byte, end: attr.SrcRange.End.Byte})
 	}
+	for _, block := range body.Blocks {
+		items = append(items, bodyItem{name: block.Type, block: block, start: block.Range().Start.Byte, end: block.Range().End.Byte})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].start < items[j].start })
+	return items, true
+}
 
-	// Return the formatted output
-	return file.Bytes()
+// singleSpaced reports whether blocks are separated by a single newline
+// rather than a blank line. Block spacings other than 1 count as 2.
+func (f *Formatter) singleSpaced() bool {
+	return f.Config.BlockSpacing == 1
 }
 
-// sortVariableBlocks alphabetically sorts variables within variable blocks
-func (f *Formatter) sortVariableBlocks(in []byte) []byte {
-	// Parse the HCL content
-	file, err := hclwrite.ParseConfig(in, "", hcl.InitialPos)
-	if err != nil {
-		// If there's an error parsing, return the original content unchanged
+// fragmentEnding returns a pass that gives the output the same trailing
+// newlines as content, for formatting fragments of a file
+func fragmentEnding(content []byte) pass {
+	ending := content[len(bytes.TrimRight(content, "\n")):]
+	return pass{"trailing_newlines", func(in []byte) []byte {
+		out := bytes.TrimRight(in, "\n")
+		return append(out, ending...)
+	}}
+}
+
+// sortResourceInputs alphabetically sorts the inputs within resource
+// blocks, and within the content of their dynamic blocks. The attributes
+// are moved as whole lines, so the comments directly above them and those
+// trailing them move along, and nested blocks stay where they are. The
+// names of body attributes are always identifiers: a quoted name like
+// "tag:Name" can only be an object key, which moves with the value holding
+// it, and a body written with one doesn't parse and is left alone.
+func (f *Formatter) sortResourceInputs(in []byte) []byte {
+	if !f.reorderable("resource") {
+		return in
+	}
+	file, diags := hclsyntax.ParseConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
 		return in
 	}
 
-	// Get all variable blocks
-	var varBlocks []*hclwrite.Block
-	for _, block := range file.Body().Blocks() {
-		if block.Type() == "variable" {
-			varBlocks = append(varBlocks, block)
+	// Find the bodies to sort by where their blocks start, since
+	// reorderBodies parses the source again
+	sorted := map[int]bool{}
+	for _, block := range body.Blocks {
+		if block.Type == "resource" {
+			sorted[block.TypeRange.Start.Byte] = true
+			markDynamicContent(block.Body, sorted)
 		}
 	}
 
-	// If there are multiple variable blocks, sort them by their labels
-	if len(varBlocks) > 1 {
-		// Remove all variable blocks from the file body
-		for _, block := range varBlocks {
-			file.Body().RemoveBlock(block)
+	less := f.nameLess()
+	if len(f.Config.AttributeOrder) > 0 {
+		less = priorityLess(f.Config.AttributeOrder, less)
+	}
+	order := func(block *hclsyntax.Block, items []bodyItem) []bodyItem {
+		if block == nil || !sorted[block.TypeRange.Start.Byte] {
+			return nil
 		}
-
-		// Sort variable blocks by their labels (names)
-		sort.Slice(varBlocks, func(i, j int) bool {
-			if len(varBlocks[i].Labels()) == 0 || len(varBlocks[j].Labels()) == 0 {
-				return false
+		var attrs []bodyItem
+		for _, item := range items {
+			if item.block == nil {
+				attrs = append(attrs, item)
 			}
-			return string(varBlocks[i].Labels()[0]) < string(varBlocks[j].Labels()[0])
+		}
+		sort.SliceStable(attrs, func(i, j int) bool {
+			return less(attrs[i].name, attrs[j].name)
 		})
 
-		// Add the blocks back in sorted order
-		for i, block := range varBlocks {
-			file.Body().AppendBlock(block)
+		// The sorted attributes fill the positions attributes had
+		ordered := make([]bodyItem, 0, len(items))
+		for _, item := range items {
+			if item.block == nil {
+				item, attrs = attrs[0], attrs[1:]
+			}
+			ordered = append(ordered, item)
+		}
+		return ordered
+	}
+	return reorderBodies(in, order, f.Config.NoReorderTypes)
+}
+
+// priorityLess orders the names in priority in the order given, with the
+// others sorted by less where priority holds "*", or after the listed ones
+// when it doesn't
+func priorityLess(priority []string, less func(a, b string) bool) func(a, b string) bool {
+	rest := slices.Index(priority, "*")
+	if rest < 0 {
+		rest = len(priority)
+	}
+	rank := func(name string) int {
+		if i := slices.Index(priority, name); i >= 0 {
+			return i
+		}
+		return rest
+	}
+	return func(a, b string) bool {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		return less(a, b)
+	}
+}
 
-			// Add a newline between blocks except after the last block
-			if i < len(varBlocks)-1 {
-				file.Body().AppendNewline()
+// markDynamicContent records the start of the content blocks of the
+// dynamic blocks nested anywhere in body. The for_each, iterator and
+// labels of the dynamic blocks themselves stay as they are.
+func markDynamicContent(body *hclsyntax.Body, marked map[int]bool) {
+	for _, block := range body.Blocks {
+		if block.Type != "dynamic" {
+			markDynamicContent(block.Body, marked)
+			continue
+		}
+		for _, content := range block.Body.Blocks {
+			if content.Type == "content" {
+				marked[content.TypeRange.Start.Byte] = true
+				markDynamicContent(content.Body, marked)
 			}
 		}
 	}
+}
+
+// sortVariableBlocks alphabetically sorts variables within variable blocks
+func (f *Formatter) sortVariableBlocks(in []byte) []byte {
+	isVariable := func(block *hclsyntax.Block) bool {
+		return block.Type == "variable" && f.reorderable("variable")
+	}
+	less := firstLabelLess(f.nameLess())
+	if len(f.Config.VarsFirst) > 0 {
+		less = pinnedFirstLess(f.Config.VarsFirst, f.nameLess())
+	}
+	return sortBlocks(in, isVariable, less, f.Config.NoSortCommentBlocks)
+}
+
+// sortDataBlocks sorts data blocks by their type and name
+func (f *Formatter) sortDataBlocks(in []byte) []byte {
+	isData := func(block *hclsyntax.Block) bool {
+		return block.Type == "data" && f.reorderable("data")
+	}
+	return sortBlocks(in, isData, labelsLess(f.nameLess()), f.Config.NoSortCommentBlocks)
+}
+
+// sortOutputBlocks sorts output blocks by their name
+func (f *Formatter) sortOutputBlocks(in []byte) []byte {
+	isOutput := func(block *hclsyntax.Block) bool {
+		return block.Type == "output" && f.reorderable("output")
+	}
+	return sortBlocks(in, isOutput, firstLabelLess(f.nameLess()), f.Config.NoSortCommentBlocks)
+}
 
-	// Return the formatted output
-	return file.Bytes()
+// dynamicOrder puts for_each, iterator and labels first and content last
+// inside dynamic blocks
+var dynamicOrder = rankedOrder("dynamic",
+	map[string]int{"for_each": 0, "iterator": 1, "labels": 2},
+	map[string]int{"content": 0},
+)
+
+// lifecycleOrder puts the arguments of lifecycle blocks in the order the
+// terraform documentation lists them, with the precondition and
+// postcondition blocks last
+var lifecycleOrder = rankedOrder("lifecycle",
+	map[string]int{"create_before_destroy": 0, "prevent_destroy": 1, "ignore_changes": 2, "replace_triggered_by": 3},
+	map[string]int{"precondition": 0, "postcondition": 1},
+)
+
+// itemOrder returns order, kept from moving attributes and nested blocks
+// past each other under -preserve-interleaving
+func (f *Formatter) itemOrder(order bodyOrder) bodyOrder {
+	if f.Config.PreserveInterleaving {
+		return keepInterleaving(order)
+	}
+	return order
+}
+
+// nameLess returns the order attribute names and labels are sorted in
+func (f *Formatter) nameLess() func(a, b string) bool {
+	return nameLess(f.Config.SortCaseInsensitive)
+}
+
+// reorderable reports whether blocks of blockType may be moved or have
+// their content reordered
+func (f *Formatter) reorderable(blockType string) bool {
+	return !slices.Contains(f.Config.NoReorderTypes, blockType)
 }
 
 // FormatFile formats the content of a terraform file and determines if it changed
@@ -175,3 +580,18 @@ func (f *Formatter) FormatFile(content []byte) (formatted []byte, changed bool)
 	formatted = f.Format(content)
 	return formatted, !bytes.Equal(content, formatted)
 }
+
+// FormatStream reads all of r, formats it like FormatFile and writes the
+// result to w, reporting whether formatting changed the content. Nothing
+// is written when reading fails.
+func (f *Formatter) FormatStream(r io.Reader, w io.Writer) (changed bool, err error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+	formatted, changed := f.FormatFile(content)
+	if _, err := w.Write(formatted); err != nil {
+		return changed, err
+	}
+	return changed, nil
+}
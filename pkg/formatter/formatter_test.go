@@ -1,6 +1,9 @@
 package formatter
 
 import (
+	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/krewenki/tffmt/pkg/config"
@@ -211,6 +214,156 @@ resource "aws_instance" "db" {
 	}
 }
 
+// TestSortInputsQuotedNames verifies quoted object keys survive sorting
+// intact and that a body with a quoted attribute name isn't touched
+func TestSortInputsQuotedNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "quoted object keys",
+			input:    "resource \"a\" \"b\" {\n  zone = 1\n  tags = { \"tag:Name\" = \"x\", \"kubernetes.io/role\" = \"y\" }\n  ami = 2\n}\n",
+			expected: "resource \"a\" \"b\" {\n  ami = 2\n  tags = { \"tag:Name\" = \"x\", \"kubernetes.io/role\" = \"y\" }\n  zone = 1\n}\n",
+		},
+		{
+			name:     "quoted attribute name",
+			input:    "resource \"a\" \"b\" {\n  zone = 1\n  \"tag:Name\" = \"x\"\n  ami = 2\n}\n",
+			expected: "resource \"a\" \"b\" {\n  zone = 1\n  \"tag:Name\" = \"x\"\n  ami = 2\n}\n",
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.SortInputs = true
+	formatter := New(cfg)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatter.sortResourceInputs([]byte(tt.input)); string(got) != tt.expected {
+				t.Errorf("sortResourceInputs() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSortInputsComments verifies comments above and trailing attributes
+// move with them when sorting inputs, and nested blocks stay in place
+func TestSortInputsComments(t *testing.T) {
+	input := `resource "aws_instance" "web" {
+  # Burstable is enough for now
+  instance_type = "t3.micro"
+  ami           = "ami-12345" # pinned
+
+  root_block_device {
+    volume_size = 20
+  }
+
+  # Must be unique per account
+  // see the naming doc
+  tags = {
+    Name = "web" # shown in the console
+  }
+  monitoring = true # costs extra
+}
+`
+	expected := `resource "aws_instance" "web" {
+  ami = "ami-12345" # pinned
+  # Burstable is enough for now
+  instance_type = "t3.micro"
+
+  root_block_device {
+    volume_size = 20
+  }
+
+  monitoring = true # costs extra
+  # Must be unique per account
+  # see the naming doc
+  tags = {
+    Name = "web" # shown in the console
+  }
+
+}
+
+`
+	cfg := config.NewConfig()
+	cfg.SortInputs = true
+	if formatted := New(cfg).Format([]byte(input)); string(formatted) != expected {
+		t.Errorf("Format() =\n%s\nwant:\n%s", formatted, expected)
+	}
+}
+
+// TestAttributeOrder verifies -attribute-order puts the listed attributes
+// where they are listed, and the rest sorted by name in place of "*"
+func TestAttributeOrder(t *testing.T) {
+	input := "resource \"aws_vpc\" \"main\" {\ntags = {}\ncidr_block = \"10.0.0.0/16\"\ndescription = \"main\"\nenable_dns = true\nname = \"main\"\n}\n"
+	tests := []struct {
+		name     string
+		order    []string
+		expected string
+	}{
+		{
+			name:     "wildcard in the middle",
+			order:    []string{"name", "description", "*", "tags"},
+			expected: "resource \"aws_vpc\" \"main\" {\nname = \"main\"\ndescription = \"main\"\ncidr_block = \"10.0.0.0/16\"\nenable_dns = true\ntags = {}\n}\n",
+		},
+		{
+			name:     "no wildcard",
+			order:    []string{"tags", "name"},
+			expected: "resource \"aws_vpc\" \"main\" {\ntags = {}\nname = \"main\"\ncidr_block = \"10.0.0.0/16\"\ndescription = \"main\"\nenable_dns = true\n}\n",
+		},
+		{
+			name:     "wildcard first",
+			order:    []string{"*", "name"},
+			expected: "resource \"aws_vpc\" \"main\" {\ncidr_block = \"10.0.0.0/16\"\ndescription = \"main\"\nenable_dns = true\ntags = {}\nname = \"main\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.SortInputs = true
+			cfg.AttributeOrder = tt.order
+			if sorted := New(cfg).sortResourceInputs([]byte(input)); string(sorted) != tt.expected {
+				t.Errorf("sortResourceInputs() = %q, want %q", sorted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSortDynamicContent verifies sorting inputs reaches the content of
+// dynamic blocks while leaving the meta-arguments of the dynamic block be
+func TestSortDynamicContent(t *testing.T) {
+	input := `resource "aws_security_group" "web" {
+  dynamic "ingress" {
+    iterator = port
+    for_each = var.ports
+    content {
+      to_port = port.value
+      protocol = "tcp"
+      from_port = port.value
+    }
+  }
+}
+`
+	expected := `resource "aws_security_group" "web" {
+  dynamic "ingress" {
+    iterator = port
+    for_each = var.ports
+    content {
+      from_port = port.value
+      protocol = "tcp"
+      to_port = port.value
+    }
+  }
+}
+`
+	cfg := config.NewConfig()
+	cfg.SortInputs = true
+	if sorted := New(cfg).sortResourceInputs([]byte(input)); string(sorted) != expected {
+		t.Errorf("sortResourceInputs() = %q, want %q", sorted, expected)
+	}
+}
+
 // TestSortVars verifies the sort-vars functionality
 func TestSortVars(t *testing.T) {
 	tests := []struct {
@@ -367,3 +520,194 @@ output "instance_ip" {
 		})
 	}
 }
+
+// TestFormatStats verifies the rules that changed the content are counted
+func TestFormatStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		sortVars bool
+		fired    []string
+	}{
+		{
+			name:  "already formatted",
+			input: "resource \"example\" \"test\" {\n  foo = bar\n}\n\n",
+			fired: nil,
+		},
+		{
+			name:  "indentation and trailing newlines",
+			input: "resource \"example\" \"test\" {\nfoo = bar\n}",
+			fired: []string{"hcl_format", "trailing_newlines"},
+		},
+		{
+			name:  "blank lines between blocks",
+			input: "block1 {}\nblock2 {}\n\n",
+			fired: []string{"blank_lines"},
+		},
+		{
+			name:  "paren split",
+			input: "x = f(\n  {}\n)\nresource \"a\" \"b\" ({})\n\n",
+			fired: []string{"preprocess", "hcl_format", "blank_lines"},
+		},
+		{
+			name:     "sorted variables",
+			input:    "variable \"b\" {}\n\nvariable \"a\" {}\n\n",
+			sortVars: true,
+			fired:    []string{"sort_vars"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.SortVars = tt.sortVars
+			formatter := New(cfg)
+
+			formatted, stats := formatter.FormatStats([]byte(tt.input))
+			if string(formatted) != string(formatter.Format([]byte(tt.input))) {
+				t.Errorf("FormatStats() output differs from Format()")
+			}
+
+			fired := map[string]bool{}
+			for _, rule := range tt.fired {
+				fired[rule] = true
+			}
+			for rule, n := range stats {
+				want := 0
+				if fired[rule] {
+					want = 1
+				}
+				if n != want {
+					t.Errorf("FormatStats() %s = %d, want %d", rule, n, want)
+				}
+			}
+			for rule := range fired {
+				if _, ok := stats[rule]; !ok {
+					t.Errorf("FormatStats() missing rule %s", rule)
+				}
+			}
+		})
+	}
+}
+
+// TestSortCaseInsensitive verifies names are sorted ignoring case, with
+// names that differ only in case put in byte order, so "Foo" always sorts
+// before "foo" whatever order they were written in
+func TestSortCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "resource inputs",
+			input:    "resource \"a\" \"b\" {\n  foo   = 1\n  Bar   = 2\n  Foo   = 3\n  alpha = 4\n}\n",
+			expected: "resource \"a\" \"b\" {\n  alpha = 4\n  Bar   = 2\n  Foo   = 3\n  foo   = 1\n}\n\n",
+		},
+		{
+			name:     "variables",
+			input:    "variable \"b\" {}\n\nvariable \"a\" {}\n\nvariable \"A\" {}\n",
+			expected: "variable \"A\" {}\n\nvariable \"a\" {}\n\nvariable \"b\" {}\n\n",
+		},
+		{
+			name:     "locals",
+			input:    "locals {\n  foo = 1\n  Foo = 2\n  bar = 3\n}\n",
+			expected: "locals {\n  bar = 3\n  Foo = 2\n  foo = 1\n}\n\n",
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.SortInputs = true
+	cfg.SortVars = true
+	cfg.SortLocals = true
+	cfg.SortCaseInsensitive = true
+	formatter := New(cfg)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatter.Format([]byte(tt.input)); string(got) != tt.expected {
+				t.Errorf("Format() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// failingReader fails every read
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+// TestFormatStream verifies content is read, formatted and written out,
+// with changed reporting whether formatting did anything
+func TestFormatStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		wantChanged bool
+	}{
+		{"unformatted", "resource \"a\" \"b\" {\nfoo   = 1\n}", "resource \"a\" \"b\" {\n  foo = 1\n}\n\n", true},
+		{"formatted", "resource \"a\" \"b\" {\n  foo = 1\n}\n\n", "resource \"a\" \"b\" {\n  foo = 1\n}\n\n", false},
+	}
+
+	formatter := New(config.NewConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			changed, err := formatter.FormatStream(strings.NewReader(tt.input), &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("FormatStream() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("FormatStream() wrote %q, want %q", out.String(), tt.expected)
+			}
+		})
+	}
+
+	t.Run("read error", func(t *testing.T) {
+		var out bytes.Buffer
+		if _, err := formatter.FormatStream(failingReader{}, &out); err == nil {
+			t.Error("FormatStream() error = nil, want the read error")
+		}
+		if out.Len() != 0 {
+			t.Errorf("FormatStream() wrote %q after a failed read", out.String())
+		}
+	})
+}
+
+// TestBlockSpacing verifies a block spacing of 1 leaves no blank lines
+// between top-level blocks, while 2 keeps one. Blank lines inside blocks
+// keep their alignment groups apart either way.
+func TestBlockSpacing(t *testing.T) {
+	input := "variable \"a\" {\ntype = string\n}\n\n\n# VPC\n\nresource \"aws_vpc\" \"main\" {\n  name = \"x\"\n  long_name = 1\n\n  z = 2\n  zz_long = 3\n}\nresource \"aws_subnet\" \"a\" {}\n"
+	tests := []struct {
+		name     string
+		spacing  int
+		expected string
+	}{
+		{
+			name:     "single",
+			spacing:  1,
+			expected: "variable \"a\" {\n  type = string\n}\n# VPC\nresource \"aws_vpc\" \"main\" {\n  name      = \"x\"\n  long_name = 1\n\n  z       = 2\n  zz_long = 3\n}\nresource \"aws_subnet\" \"a\" {}\n\n",
+		},
+		{
+			name:     "double",
+			spacing:  2,
+			expected: "variable \"a\" {\n  type = string\n}\n\n# VPC\n\nresource \"aws_vpc\" \"main\" {\n  name      = \"x\"\n  long_name = 1\n\n  z       = 2\n  zz_long = 3\n}\n\nresource \"aws_subnet\" \"a\" {}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.BlockSpacing = tt.spacing
+			if formatted := New(cfg).Format([]byte(input)); string(formatted) != tt.expected {
+				t.Errorf("Format() = %q, want %q", formatted, tt.expected)
+			}
+		})
+	}
+}
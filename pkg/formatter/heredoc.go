@@ -0,0 +1,147 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// fixHeredocIndent reindents the bodies of indented (<<-) heredocs so they
+// sit one level deeper than the line that opens them, with the closing
+// marker aligned to that line. Terraform strips the common leading
+// whitespace of these heredocs by character count, so replacing that
+// common prefix keeps the resulting string identical even when the source
+// mixes tabs and spaces. Plain (<<) heredocs are left untouched because
+// their whitespace is significant.
+func fixHeredocIndent(in []byte) []byte {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for i := 0; i < len(tokens); i++ {
+		open := tokens[i]
+		if open.Type != hclsyntax.TokenOHeredoc || !bytes.HasPrefix(open.Bytes, []byte("<<-")) {
+			continue
+		}
+
+		// Find the matching closing marker
+		j := i + 1
+		for j < len(tokens) && tokens[j].Type != hclsyntax.TokenCHeredoc {
+			j++
+		}
+		if j == len(tokens) {
+			break
+		}
+		closing := tokens[j]
+
+		base := lineIndent(in, open.Range.Start.Byte)
+		bodyStart, bodyEnd := open.Range.End.Byte, closing.Range.Start.Byte
+
+		out.Write(in[last:bodyStart])
+		out.Write(reindentHeredocBody(in[bodyStart:bodyEnd], base+"  "))
+		out.WriteString(base)
+		out.Write(bytes.TrimLeft(closing.Bytes, " \t"))
+		last = closing.Range.End.Byte
+		i = j
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
+
+// nestedHeredoc reports a heredoc opened inside the interpolation of
+// another, which fixHeredocIndent would mistake for the end of the outer one
+func nestedHeredoc(in []byte) string {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return ""
+	}
+	depth := 0
+	for _, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenOHeredoc:
+			if depth > 0 {
+				return fmt.Sprintf("heredoc nested in another on line %d", tok.Range.Start.Line)
+			}
+			depth++
+		case hclsyntax.TokenCHeredoc:
+			depth--
+		}
+	}
+	return ""
+}
+
+// reindentHeredocBody replaces the common leading whitespace of the
+// non-blank lines in body with indent. Whitespace-only lines are kept as
+// they are since Terraform does not strip them either.
+func reindentHeredocBody(body []byte, indent string) []byte {
+	lines := bytes.SplitAfter(body, []byte("\n"))
+
+	common := -1
+	for _, line := range lines {
+		if isBlankLine(line) {
+			continue
+		}
+		if n := leadingSpaceCount(line); common < 0 || n < common {
+			common = n
+		}
+	}
+	if common < 0 {
+		return body
+	}
+
+	var out bytes.Buffer
+	for _, line := range lines {
+		if isBlankLine(line) {
+			out.Write(line)
+			continue
+		}
+		out.WriteString(indent)
+		out.Write(dropLeadingChars(line, common))
+	}
+	return out.Bytes()
+}
+
+// lineIndent returns the leading whitespace of the line containing offset
+func lineIndent(src []byte, offset int) string {
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
+
+// isBlankLine reports whether line consists only of whitespace
+func isBlankLine(line []byte) bool {
+	return len(bytes.TrimFunc(line, unicode.IsSpace)) == 0
+}
+
+// leadingSpaceCount counts the whitespace characters at the start of line
+func leadingSpaceCount(line []byte) int {
+	n := 0
+	for len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		if r == '\n' || !unicode.IsSpace(r) {
+			break
+		}
+		n++
+		line = line[size:]
+	}
+	return n
+}
+
+// dropLeadingChars removes the first n characters from line
+func dropLeadingChars(line []byte, n int) []byte {
+	for i := 0; i < n && len(line) > 0; i++ {
+		_, size := utf8.DecodeRune(line)
+		line = line[size:]
+	}
+	return line
+}
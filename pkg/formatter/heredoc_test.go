@@ -0,0 +1,90 @@
+package formatter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestFixHeredocIndent verifies indented heredocs are reindented while
+// plain heredocs keep their whitespace
+func TestFixHeredocIndent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		fixFlag  bool
+	}{
+		{
+			name:     "indented heredoc with mixed indentation",
+			input:    "resource \"a\" \"b\" {\n  script = <<-EOT\n\t  echo one\n        echo two\n      EOT\n}\n",
+			expected: "resource \"a\" \"b\" {\n  script = <<-EOT\n    echo one\n         echo two\n  EOT\n}\n\n",
+			fixFlag:  true,
+		},
+		{
+			name:     "indented heredoc keeps relative indentation and blank lines",
+			input:    "locals {\n  names = <<-EOT\n[\n  \"a\",\n\n  \"b\"\n]\nEOT\n}\n",
+			expected: "locals {\n  names = <<-EOT\n    [\n      \"a\",\n\n      \"b\"\n    ]\n  EOT\n}\n\n",
+			fixFlag:  true,
+		},
+		{
+			name:     "plain heredoc untouched",
+			input:    "resource \"a\" \"b\" {\n  script = <<EOT\n\t  echo one\n        echo two\nEOT\n}\n",
+			expected: "resource \"a\" \"b\" {\n  script = <<EOT\n\t  echo one\n        echo two\nEOT\n}\n\n",
+			fixFlag:  true,
+		},
+		{
+			name:     "flag disabled",
+			input:    "resource \"a\" \"b\" {\n  script = <<-EOT\n\t  echo one\n      EOT\n}\n",
+			expected: "resource \"a\" \"b\" {\n  script = <<-EOT\n\t  echo one\n      EOT\n}\n\n",
+			fixFlag:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.FixHeredocIndent = tt.fixFlag
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(tt.input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() with fix-heredoc-indent=%v = %q, want %q", tt.fixFlag, formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSkipUnsafePasses verifies a heredoc nested in another opts out of
+// heredoc reindenting with a warning while the other rules still run
+func TestSkipUnsafePasses(t *testing.T) {
+	input := "locals {\nfoo=1\n  script = <<-EOT\n      ${<<-INNER\n        x\n      INNER\n      }\n      EOT\n}\n"
+
+	cfg := config.NewConfig()
+	cfg.FixHeredocIndent = true
+	cfg.SkipUnsafePasses = true
+	formatted, stats, warnings := New(cfg).FormatWarnings([]byte(input))
+
+	expected := "locals {\n  foo = 1\n  script = <<-EOT\n      ${<<-INNER\n        x\n      INNER\n}\n      EOT\n}\n\n"
+	if string(formatted) != expected {
+		t.Errorf("FormatWarnings() = %q, want %q", formatted, expected)
+	}
+	if stats["hcl_format"] != 1 {
+		t.Errorf("hcl_format count = %d, want 1", stats["hcl_format"])
+	}
+	want := []Warning{{"heredoc_indent", "heredoc nested in another on line 4"}}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %v, want %v", warnings, want)
+	}
+
+	// Without the option the rule runs anyway and nothing is reported
+	cfg.SkipUnsafePasses = false
+	unsafe, _, warnings := New(cfg).FormatWarnings([]byte(input))
+	if warnings != nil {
+		t.Errorf("warnings without skip-unsafe-passes = %v, want none", warnings)
+	}
+	if string(unsafe) == expected {
+		t.Errorf("FormatWarnings() without skip-unsafe-passes left the heredoc alone")
+	}
+}
@@ -0,0 +1,30 @@
+package formatter
+
+import "bytes"
+
+// reindent returns a pass that changes the two-space indentation written
+// by canonical formatting to width spaces per level. Lines inside heredoc
+// bodies and quoted strings are part of values and keep their whitespace,
+// as do lines indented with tabs.
+func reindent(width int) func([]byte) []byte {
+	return func(in []byte) []byte {
+		spans := literalSpans(in)
+		var out bytes.Buffer
+		for start := 0; start < len(in); {
+			end := len(in)
+			if nl := bytes.IndexByte(in[start:], '\n'); nl >= 0 {
+				end = start + nl + 1
+			}
+			line := in[start:end]
+			spaces := len(line) - len(bytes.TrimLeft(line, " "))
+			if spaces == 0 || overlapsAny(start, start+1, spans) || bytes.HasPrefix(line[spaces:], []byte("\t")) {
+				out.Write(line)
+			} else {
+				out.Write(bytes.Repeat([]byte(" "), spaces/2*width+spaces%2))
+				out.Write(line[spaces:])
+			}
+			start = end
+		}
+		return out.Bytes()
+	}
+}
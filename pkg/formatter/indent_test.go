@@ -0,0 +1,60 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestReindent verifies nested content is indented by the configured width
+// while heredoc bodies keep their whitespace
+func TestReindent(t *testing.T) {
+	tests := []struct {
+		name     string
+		width    int
+		input    string
+		expected string
+	}{
+		{
+			name:     "four spaces",
+			width:    4,
+			input:    "resource \"a\" \"b\" {\n  tags = {\n    Name = \"x\"\n  }\n  # done\n}\n",
+			expected: "resource \"a\" \"b\" {\n    tags = {\n        Name = \"x\"\n    }\n    # done\n}\n",
+		},
+		{
+			name:     "heredoc body",
+			width:    4,
+			input:    "locals {\n  script = <<EOT\n  echo hi\nEOT\n  other = \"  x\"\n}\n",
+			expected: "locals {\n    script = <<EOT\n  echo hi\nEOT\n    other = \"  x\"\n}\n",
+		},
+		{
+			name:     "tabs",
+			width:    4,
+			input:    "locals {\n\t  a = 1\n}\n",
+			expected: "locals {\n\t  a = 1\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reindent(tt.width)([]byte(tt.input)); string(got) != tt.expected {
+				t.Errorf("reindent(%d) = %q, want %q", tt.width, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestIndent verifies formatting indents by the configured width
+func TestIndent(t *testing.T) {
+	input := "resource \"a\" \"b\" {\nfoo = 1\n}\n"
+	for indent, expected := range map[int]string{
+		2: "resource \"a\" \"b\" {\n  foo = 1\n}\n\n",
+		4: "resource \"a\" \"b\" {\n    foo = 1\n}\n\n",
+	} {
+		cfg := config.NewConfig()
+		cfg.Indent = indent
+		if got := New(cfg).Format([]byte(input)); string(got) != expected {
+			t.Errorf("Format() with indent=%d = %q, want %q", indent, got, expected)
+		}
+	}
+}
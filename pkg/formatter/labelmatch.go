@@ -0,0 +1,45 @@
+package formatter
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// formatMatching formats only the top-level blocks whose name label, the
+// last one, matches the LabelMatch regular expression, leaving the rest of
+// content byte-identical. Each matching block is formatted on its own, so
+// rules that move blocks around only apply inside it, with run.
+func (f *Formatter) formatMatching(content []byte, run func(chunk []byte) []byte) []byte {
+	re, err := regexp.Compile(f.Config.LabelMatch)
+	if err != nil {
+		return content
+	}
+	file, diags := hclsyntax.ParseConfig(content, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return content
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return content
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, block := range body.Blocks {
+		if len(block.Labels) == 0 || !re.MatchString(block.Labels[len(block.Labels)-1]) {
+			continue
+		}
+		start := bytes.LastIndexByte(content[:block.TypeRange.Start.Byte], '\n') + 1
+		end := block.CloseBraceRange.End.Byte
+		chunk := content[start:end]
+
+		out.Write(content[last:start])
+		out.Write(run(chunk))
+		last = end
+	}
+	out.Write(content[last:])
+	return out.Bytes()
+}
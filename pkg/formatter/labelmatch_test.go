@@ -0,0 +1,61 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestLabelMatch verifies only blocks whose name label matches are
+// formatted, leaving the others byte-identical
+func TestLabelMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "matching and other blocks",
+			input: `resource "aws_db_instance" "legacy_db" {
+engine = "postgres"
+  instance_class="db.t3.micro"
+}
+resource "aws_db_instance" "new_db" {
+engine = "postgres"
+  instance_class="db.t3.micro"
+}
+`,
+			expected: `resource "aws_db_instance" "legacy_db" {
+  engine         = "postgres"
+  instance_class = "db.t3.micro"
+}
+resource "aws_db_instance" "new_db" {
+engine = "postgres"
+  instance_class="db.t3.micro"
+}
+`,
+		},
+		{
+			name:     "sorting inside a matching block",
+			input:    "variable \"legacy_name\" {\n  type = string\n}\nresource \"a\" \"legacy_b\" {\n  z = 1\n  a = 2\n}\nresource \"a\" \"c\" {\n  z = 1\n  a = 2\n}\n",
+			expected: "variable \"legacy_name\" {\n  type = string\n}\nresource \"a\" \"legacy_b\" {\n  a = 2\n  z = 1\n}\nresource \"a\" \"c\" {\n  z = 1\n  a = 2\n}\n",
+		},
+		{
+			name:     "no matching blocks",
+			input:    "resource \"a\" \"b\" {\nfoo=1\n}",
+			expected: "resource \"a\" \"b\" {\nfoo=1\n}",
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.LabelMatch = "^legacy_"
+	cfg.SortInputs = true
+	f := New(cfg)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(f.Format([]byte(tt.input))); got != tt.expected {
+				t.Errorf("Format() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
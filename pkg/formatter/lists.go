@@ -0,0 +1,110 @@
+package formatter
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// listScope tracks a bracketed scope while normalizing list spacing
+type listScope struct {
+	open    hclsyntax.Token
+	list    bool // a "[" that doesn't start a for expression
+	comment bool // a comment sits somewhere inside
+	gaps    map[int]listGap
+}
+
+// listGap is the whitespace between two tokens and what replaces it
+type listGap struct {
+	end  int
+	with string
+}
+
+// normalizeListSpacing rewrites single-line lists to the ["a", "b"] style:
+// no padding inside the brackets, no space before commas and a single
+// space after them. Nested lists are normalized on their own. Lists that
+// span lines or hold comments, and for expressions, are left alone.
+func normalizeListSpacing(in []byte) []byte {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+
+	gaps := map[int]listGap{}
+	var stack []*listScope
+	gap := func(scope *listScope, i int, with string) {
+		if i+1 < len(tokens) {
+			scope.gaps[tokens[i].Range.End.Byte] = listGap{tokens[i+1].Range.Start.Byte, with}
+		}
+	}
+	for i, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenOBrack, hclsyntax.TokenOBrace, hclsyntax.TokenOParen,
+			hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			scope := &listScope{open: tok, gaps: map[int]listGap{}}
+			if tok.Type == hclsyntax.TokenOBrack {
+				isFor := i+1 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenIdent && string(tokens[i+1].Bytes) == "for"
+				scope.list = !isFor
+				gap(scope, i, "")
+			}
+			stack = append(stack, scope)
+			continue
+		case hclsyntax.TokenCBrack, hclsyntax.TokenCBrace, hclsyntax.TokenCParen, hclsyntax.TokenTemplateSeqEnd:
+			if len(stack) == 0 {
+				continue
+			}
+			scope := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 && scope.comment {
+				stack[len(stack)-1].comment = true
+			}
+			if scope.list && !scope.comment && tok.Type == hclsyntax.TokenCBrack &&
+				scope.open.Range.Start.Line == tok.Range.End.Line {
+				gap(scope, i-1, "")
+				for start, g := range scope.gaps {
+					gaps[start] = g
+				}
+			}
+			continue
+		case hclsyntax.TokenComment:
+			if len(stack) > 0 {
+				stack[len(stack)-1].comment = true
+			}
+		}
+
+		if len(stack) == 0 || !stack[len(stack)-1].list {
+			continue
+		}
+		if tok.Type == hclsyntax.TokenComma {
+			scope := stack[len(stack)-1]
+			gap(scope, i-1, "")
+			if i+1 < len(tokens) && tokens[i+1].Type != hclsyntax.TokenCBrack {
+				gap(scope, i, " ")
+			}
+		}
+	}
+
+	// Replace the gaps holding nothing but spaces that need to change
+	starts := make([]int, 0, len(gaps))
+	for start, g := range gaps {
+		if ws := in[start:g.end]; len(bytes.Trim(ws, " \t")) == 0 && string(ws) != g.with {
+			starts = append(starts, start)
+		}
+	}
+	if len(starts) == 0 {
+		return in
+	}
+	sort.Ints(starts)
+
+	var out bytes.Buffer
+	last := 0
+	for _, start := range starts {
+		out.Write(in[last:start])
+		out.WriteString(gaps[start].with)
+		last = gaps[start].end
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
@@ -0,0 +1,72 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestNormalizeListSpacing verifies single-line lists lose their inner
+// padding and get a single space after each comma
+func TestNormalizeListSpacing(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "padded list",
+			input:    "names = [ \"a\",\"b\" ]\n",
+			expected: "names = [\"a\", \"b\"]\n",
+		},
+		{
+			name:     "nested lists",
+			input:    "names = [[\"a\"],[ \"b\" ,\"c\"]]\n",
+			expected: "names = [[\"a\"], [\"b\", \"c\"]]\n",
+		},
+		{
+			name:     "trailing comma and empty list",
+			input:    "names = [ \"a\", ]\nnone = [ ]\n",
+			expected: "names = [\"a\",]\nnone = []\n",
+		},
+		{
+			name:     "call arguments keep their own spacing",
+			input:    "names = [ join(\",\",var.a) ]\n",
+			expected: "names = [join(\",\",var.a)]\n",
+		},
+		{
+			name:     "for expression",
+			input:    "names = [ for x in var.a : x ]\n",
+			expected: "names = [ for x in var.a : x ]\n",
+		},
+		{
+			name:     "multi-line list",
+			input:    "names = [\n  \"a\" ,\n  \"b\",\n]\n",
+			expected: "names = [\n  \"a\" ,\n  \"b\",\n]\n",
+		},
+		{
+			name:     "list with a comment",
+			input:    "names = [ \"a\" /* first */,\"b\" ]\n",
+			expected: "names = [ \"a\" /* first */,\"b\" ]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeListSpacing([]byte(tt.input)); string(got) != tt.expected {
+				t.Errorf("normalizeListSpacing() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestListSpacingFlag verifies lists come out normalized when formatting
+// with the rule enabled
+func TestListSpacingFlag(t *testing.T) {
+	input := "names = [[ \"a\" ],[\"b\",\"c\"]]\n"
+	cfg := config.NewConfig()
+	cfg.NormalizeListSpacing = true
+	if got, want := New(cfg).Format([]byte(input)), "names = [[\"a\"], [\"b\", \"c\"]]\n\n"; string(got) != want {
+		t.Errorf("Format() with normalize-list-spacing = %q, want %q", got, want)
+	}
+}
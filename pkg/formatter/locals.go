@@ -0,0 +1,74 @@
+package formatter
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// mergeLocals moves the content of every top-level locals block into the
+// first one, in source order, and removes the emptied blocks. Comments
+// above the removed blocks are moved along with their content.
+func mergeLocals(in []byte) []byte {
+	file, diags := hclwrite.ParseConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+
+	var locals []*hclwrite.Block
+	for _, block := range file.Body().Blocks() {
+		if block.Type() == "locals" {
+			locals = append(locals, block)
+		}
+	}
+	if len(locals) < 2 {
+		return in
+	}
+
+	first := locals[0].Body()
+	for _, block := range locals[1:] {
+		first.AppendUnstructuredTokens(leadComments(block))
+		first.AppendUnstructuredTokens(bodyContent(block.Body()))
+		file.Body().RemoveBlock(block)
+	}
+	return file.Bytes()
+}
+
+// leadComments returns the comment tokens directly above block
+func leadComments(block *hclwrite.Block) hclwrite.Tokens {
+	var comments hclwrite.Tokens
+	for _, tok := range block.BuildTokens(nil) {
+		if tok.Type != hclsyntax.TokenComment {
+			break
+		}
+		comments = append(comments, tok)
+	}
+	return comments
+}
+
+// bodyContent returns the tokens of body without the newline that follows
+// the opening brace
+func bodyContent(body *hclwrite.Body) hclwrite.Tokens {
+	tokens := body.BuildTokens(nil)
+	if len(tokens) > 0 && tokens[0].Type == hclsyntax.TokenNewline {
+		return tokens[1:]
+	}
+	return tokens
+}
+
+// sortLocals alphabetically sorts the values within each locals block in
+// the order of less. Comments directly above a value move with it, while
+// standalone comments and blank lines stay where they are.
+func sortLocals(in []byte, less func(a, b string) bool) []byte {
+	order := func(block *hclsyntax.Block, items []bodyItem) []bodyItem {
+		if block == nil || block.Type != "locals" {
+			return nil
+		}
+		sorted := append([]bodyItem(nil), items...)
+		sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i].name, sorted[j].name) })
+		return sorted
+	}
+	return reorderBodies(in, order, nil)
+}
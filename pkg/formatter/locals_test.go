@@ -0,0 +1,110 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestMergeLocals verifies multiple locals blocks are merged into the first
+func TestMergeLocals(t *testing.T) {
+	input := `locals {
+  region = "us-east-1"
+}
+
+variable "name" {}
+
+# naming
+locals {
+  prefix = "app" # short
+}
+
+locals {
+  # retention in days
+  retention = 30
+  enabled   = true
+}
+`
+	tests := []struct {
+		name     string
+		sort     bool
+		expected string
+	}{
+		{
+			name: "merged in order",
+			expected: `locals {
+  region = "us-east-1"
+  # naming
+  prefix = "app" # short
+  # retention in days
+  retention = 30
+  enabled   = true
+}
+
+variable "name" {}
+
+`,
+		},
+		{
+			name: "merged and sorted",
+			sort: true,
+			expected: `locals {
+  enabled = true
+  # naming
+  prefix = "app" # short
+  region = "us-east-1"
+  # retention in days
+  retention = 30
+}
+
+variable "name" {}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.MergeLocals = true
+			cfg.SortLocals = tt.sort
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() =\n%s\nwant:\n%s", formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSortLocalsComments verifies sorting locals keeps standalone comments
+// and blank lines where they are, while comments above a value move with it
+func TestSortLocalsComments(t *testing.T) {
+	input := `locals {
+  # Section: networking
+
+  zone = 1
+  # first of all
+  alpha = 2
+
+  # trailing note
+}
+`
+	expected := `locals {
+  # Section: networking
+
+  # first of all
+  alpha = 2
+  zone  = 1
+
+  # trailing note
+}
+
+`
+	cfg := config.NewConfig()
+	cfg.SortLocals = true
+	if formatted := New(cfg).Format([]byte(input)); string(formatted) != expected {
+		t.Errorf("Format() =\n%s\nwant:\n%s", formatted, expected)
+	}
+}
@@ -0,0 +1,211 @@
+package formatter
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// edit replaces the source bytes from start to end with text
+type edit struct {
+	start, end int
+	text       []byte
+}
+
+// modernize rewrites calls to the deprecated list() and map() functions
+// as the equivalent tuple and object expressions. Calls it can't convert
+// unambiguously are left alone.
+func modernize(in []byte) []byte {
+	// Each round converts the outermost calls, so nested calls take one
+	// round per level
+	for {
+		out, changed := modernizeCalls(in)
+		if !changed {
+			return out
+		}
+		in = out
+	}
+}
+
+// modernizeCalls converts the outermost list() and map() calls in in,
+// reporting whether anything changed
+func modernizeCalls(in []byte) ([]byte, bool) {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in, false
+	}
+
+	var edits []edit
+	covered := 0
+	for i, tok := range tokens {
+		if tok.Type != hclsyntax.TokenIdent || tok.Range.Start.Byte < covered {
+			continue
+		}
+		name := string(tok.Bytes)
+		if name != "list" && name != "map" {
+			continue
+		}
+		if i+1 >= len(tokens) || tokens[i+1].Type != hclsyntax.TokenOParen {
+			continue
+		}
+		// Attribute traversals and provider functions aren't the builtins
+		if i > 0 && (tokens[i-1].Type == hclsyntax.TokenDot || tokens[i-1].Type == hclsyntax.TokenDoubleColon) {
+			continue
+		}
+
+		args, closing, ok := callArgs(tokens, i+1)
+		if !ok {
+			continue
+		}
+		var callEdits []edit
+		if name == "list" {
+			callEdits = listEdits(tok, tokens[i+1], tokens[closing])
+		} else {
+			callEdits, ok = mapEdits(in, tok, tokens[closing], tokens[i+1:closing+1], args)
+			if !ok {
+				continue
+			}
+		}
+		edits = append(edits, callEdits...)
+		covered = tokens[closing].Range.End.Byte
+	}
+	if len(edits) == 0 {
+		return in, false
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		out.Write(in[last:e.start])
+		out.Write(e.text)
+		last = e.end
+	}
+	out.Write(in[last:])
+	return out.Bytes(), true
+}
+
+// callArgs splits the arguments of the call whose opening parenthesis is
+// tokens[open], returning them along with the index of the closing
+// parenthesis. It reports false for calls that expand their last argument
+// with "..." or never close.
+func callArgs(tokens hclsyntax.Tokens, open int) ([]hclsyntax.Tokens, int, bool) {
+	var args []hclsyntax.Tokens
+	var arg hclsyntax.Tokens
+	depth := 0
+	for i := open + 1; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Type {
+		case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace,
+			hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl,
+			hclsyntax.TokenOQuote, hclsyntax.TokenOHeredoc:
+			depth++
+		case hclsyntax.TokenCBrack, hclsyntax.TokenCBrace, hclsyntax.TokenTemplateSeqEnd,
+			hclsyntax.TokenCQuote, hclsyntax.TokenCHeredoc:
+			depth--
+		case hclsyntax.TokenCParen:
+			if depth == 0 {
+				if len(significant(arg)) > 0 {
+					args = append(args, arg)
+				}
+				return args, i, true
+			}
+			depth--
+		case hclsyntax.TokenComma:
+			if depth == 0 {
+				args = append(args, arg)
+				arg = nil
+				continue
+			}
+		case hclsyntax.TokenEllipsis:
+			if depth == 0 {
+				return nil, 0, false
+			}
+		case hclsyntax.TokenEOF:
+			return nil, 0, false
+		}
+		arg = append(arg, tok)
+	}
+	return nil, 0, false
+}
+
+// significant trims the newlines and comments around an argument's tokens
+func significant(arg hclsyntax.Tokens) hclsyntax.Tokens {
+	skip := func(tok hclsyntax.Token) bool {
+		return tok.Type == hclsyntax.TokenNewline || tok.Type == hclsyntax.TokenComment
+	}
+	for len(arg) > 0 && skip(arg[0]) {
+		arg = arg[1:]
+	}
+	for len(arg) > 0 && skip(arg[len(arg)-1]) {
+		arg = arg[:len(arg)-1]
+	}
+	return arg
+}
+
+// listEdits turns list(a, b) into [a, b], keeping the arguments as written
+func listEdits(name, open, closing hclsyntax.Token) []edit {
+	return []edit{
+		{name.Range.Start.Byte, open.Range.End.Byte, []byte("[")},
+		{closing.Range.Start.Byte, closing.Range.End.Byte, []byte("]")},
+	}
+}
+
+// mapEdits turns map("k", v) into { k = v }. Only single-line calls whose
+// keys are all distinct plain string literals are converted.
+func mapEdits(src []byte, name, closing hclsyntax.Token, call hclsyntax.Tokens, args []hclsyntax.Tokens) ([]edit, bool) {
+	for _, tok := range call {
+		if tok.Type == hclsyntax.TokenNewline || tok.Type == hclsyntax.TokenComment {
+			return nil, false
+		}
+	}
+	if len(args)%2 != 0 {
+		return nil, false
+	}
+
+	var b bytes.Buffer
+	b.WriteString("{")
+	seen := map[string]bool{}
+	for i := 0; i < len(args); i += 2 {
+		key, ok := literalKey(significant(args[i]))
+		if !ok || seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+		value := significant(args[i+1])
+		if len(value) == 0 {
+			return nil, false
+		}
+
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(" ")
+		if hclsyntax.ValidIdentifier(key) {
+			b.WriteString(key)
+		} else {
+			b.WriteString(`"` + key + `"`)
+		}
+		b.WriteString(" = ")
+		b.Write(src[value[0].Range.Start.Byte:value[len(value)-1].Range.End.Byte])
+	}
+	if len(args) > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString("}")
+
+	return []edit{{name.Range.Start.Byte, closing.Range.End.Byte, b.Bytes()}}, true
+}
+
+// literalKey returns the text of a quoted string without escapes or
+// template sequences
+func literalKey(arg hclsyntax.Tokens) (string, bool) {
+	if len(arg) != 3 || arg[0].Type != hclsyntax.TokenOQuote ||
+		arg[1].Type != hclsyntax.TokenQuotedLit || arg[2].Type != hclsyntax.TokenCQuote {
+		return "", false
+	}
+	if bytes.ContainsAny(arg[1].Bytes, `\$%`) {
+		return "", false
+	}
+	return string(arg[1].Bytes), true
+}
@@ -0,0 +1,74 @@
+package formatter
+
+import (
+	"testing"
+)
+
+// TestModernize verifies list() and map() calls are rewritten only where
+// the conversion is unambiguous
+func TestModernize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "list",
+			input:    `a = list("a", "b")`,
+			expected: `a = ["a", "b"]`,
+		},
+		{
+			name:     "empty list",
+			input:    `a = list()`,
+			expected: `a = []`,
+		},
+		{
+			name:     "map",
+			input:    `a = map("k", "v")`,
+			expected: `a = { k = "v" }`,
+		},
+		{
+			name:     "map with keys that aren't identifiers",
+			input:    `a = map("app.io/name", var.x, "other", 1)`,
+			expected: `a = { "app.io/name" = var.x, other = 1 }`,
+		},
+		{
+			name:     "nested",
+			input:    `a = list(map("k", list(1, 2)), "b")`,
+			expected: `a = [{ k = [1, 2] }, "b"]`,
+		},
+		{
+			name:     "map with computed key",
+			input:    `a = map(var.key, "v")`,
+			expected: `a = map(var.key, "v")`,
+		},
+		{
+			name:     "map with odd arguments",
+			input:    `a = map("k")`,
+			expected: `a = map("k")`,
+		},
+		{
+			name:     "expanded arguments",
+			input:    `a = list(var.items...)`,
+			expected: `a = list(var.items...)`,
+		},
+		{
+			name:     "provider function named list",
+			input:    `a = provider::custom::list("a")`,
+			expected: `a = provider::custom::list("a")`,
+		},
+		{
+			name:     "attribute named list",
+			input:    "list = var.list\nb    = \"list(a)\"",
+			expected: "list = var.list\nb    = \"list(a)\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := string(modernize([]byte(tt.input))); out != tt.expected {
+				t.Errorf("modernize(%q) = %q, want %q", tt.input, out, tt.expected)
+			}
+		})
+	}
+}
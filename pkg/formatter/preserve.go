@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// preservedValues maps the path of an attribute to the original tokens of
+// its value expression
+type preservedValues map[string]hclwrite.Tokens
+
+// capturePreserved records the value tokens of every attribute, at any
+// nesting depth, whose name is in names
+func capturePreserved(src []byte, names []string) preservedValues {
+	file, diags := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	values := preservedValues{}
+	walkAttributes(file.Body(), "", func(key string, _ *hclwrite.Body, name string, attr *hclwrite.Attribute) {
+		if wanted[name] {
+			values[key] = attr.Expr().BuildTokens(nil)
+		}
+	})
+	return values
+}
+
+// restorePreserved puts the captured value tokens back into the attributes
+// they were taken from, leaving the rest of src as it is
+func restorePreserved(src []byte, values preservedValues) []byte {
+	if len(values) == 0 {
+		return src
+	}
+	file, diags := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+
+	walkAttributes(file.Body(), "", func(key string, body *hclwrite.Body, name string, _ *hclwrite.Attribute) {
+		if tokens, ok := values[key]; ok {
+			body.SetAttributeRaw(name, tokens)
+		}
+	})
+
+	// File.Bytes would reformat the restored values, so emit the raw tokens
+	return file.BuildTokens(nil).Bytes()
+}
+
+// walkAttributes calls fn for every attribute in body and its nested blocks.
+// Each attribute is identified by a key built from the types and labels of
+// the blocks containing it, so it can be found again after the blocks or
+// attributes have been reordered.
+func walkAttributes(body *hclwrite.Body, prefix string, fn func(key string, body *hclwrite.Body, name string, attr *hclwrite.Attribute)) {
+	for name, attr := range body.Attributes() {
+		fn(prefix+name, body, name, attr)
+	}
+
+	seen := make(map[string]int)
+	for _, block := range body.Blocks() {
+		id := strings.Join(append([]string{block.Type()}, block.Labels()...), " ")
+		walkAttributes(block.Body(), fmt.Sprintf("%s%s#%d/", prefix, id, seen[id]), fn)
+		seen[id]++
+	}
+}
@@ -0,0 +1,46 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestPreserveAttrs verifies configured attribute values are left
+// byte-identical while the rest of the file is formatted
+func TestPreserveAttrs(t *testing.T) {
+	input := `resource "aws_iam_policy" "example" {
+name = "example"
+  policy = jsonencode({
+      Version = "2012-10-17"
+      Statement = [{ Effect = "Allow",
+                     Action = "s3:*" }]
+  })
+}
+`
+	expected := `resource "aws_iam_policy" "example" {
+  name = "example"
+  policy = jsonencode({
+      Version = "2012-10-17"
+      Statement = [{ Effect = "Allow",
+                     Action = "s3:*" }]
+  })
+}
+
+`
+
+	cfg := config.NewConfig()
+	cfg.PreserveAttrs = []string{"policy", "assume_role_policy"}
+	formatter := New(cfg)
+
+	formatted := formatter.Format([]byte(input))
+	if string(formatted) != expected {
+		t.Errorf("Format() with preserve-attrs = %q, want %q", formatted, expected)
+	}
+
+	// Without the option the value is reformatted
+	cfg.PreserveAttrs = nil
+	if string(formatter.Format([]byte(input))) == expected {
+		t.Errorf("Format() without preserve-attrs left the policy untouched")
+	}
+}
@@ -0,0 +1,120 @@
+package formatter
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// span is a half-open byte range of the source
+type span struct {
+	start, end int
+}
+
+// literalSpans returns the byte ranges of heredoc bodies and quoted string
+// contents in src. Their bytes are part of the configuration's values, so
+// the regex passes must never touch them.
+func literalSpans(src []byte) []span {
+	tokens, _ := hclsyntax.LexConfig(src, "", hcl.InitialPos)
+
+	var spans []span
+	depth, start := 0, 0
+	for _, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenOHeredoc, hclsyntax.TokenOQuote:
+			if depth == 0 {
+				start = tok.Range.End.Byte
+			}
+			depth++
+		case hclsyntax.TokenCHeredoc, hclsyntax.TokenCQuote:
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				spans = append(spans, span{start, tok.Range.Start.Byte})
+			}
+		}
+	}
+	return spans
+}
+
+// commentGaps returns the byte ranges of the blank lines between the lines
+// of a comment, from the newline ending one comment line to the start of
+// the next. Comments trailing code don't start a comment.
+func commentGaps(src []byte) []span {
+	tokens, _ := hclsyntax.LexConfig(src, "", hcl.InitialPos)
+
+	var gaps []span
+	for i, tok := range tokens {
+		if tok.Type != hclsyntax.TokenComment || i > 0 && tokens[i-1].Type != hclsyntax.TokenNewline && tokens[i-1].Type != hclsyntax.TokenComment {
+			continue
+		}
+		j := i + 1
+		for j < len(tokens) && tokens[j].Type == hclsyntax.TokenNewline {
+			j++
+		}
+		if j == i+1 || j == len(tokens) || tokens[j].Type != hclsyntax.TokenComment {
+			continue
+		}
+		// Line comments hold their newline
+		start := tok.Range.End.Byte
+		if bytes.HasSuffix(tok.Bytes, []byte("\n")) {
+			start--
+		}
+		gaps = append(gaps, span{start, tokens[j].Range.Start.Byte})
+	}
+	return gaps
+}
+
+// replaceLiteralSafe is like re.ReplaceAll, but leaves matches that
+// overlap a heredoc body or quoted string, or any of keep, untouched
+func replaceLiteralSafe(re *regexp.Regexp, src, repl []byte, keep ...span) []byte {
+	matches := re.FindAllSubmatchIndex(src, -1)
+	if len(matches) == 0 {
+		return src
+	}
+	spans := append(literalSpans(src), keep...)
+
+	var out []byte
+	last := 0
+	for _, m := range matches {
+		if overlapsAny(m[0], m[1], spans) {
+			continue
+		}
+		out = append(out, src[last:m[0]]...)
+		out = re.Expand(out, repl, src, m)
+		last = m[1]
+	}
+	return append(out, src[last:]...)
+}
+
+// overlapsAny reports whether the byte range [start, end) overlaps any of spans
+func overlapsAny(start, end int, spans []span) bool {
+	for _, s := range spans {
+		if start < s.end && end > s.start {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLiteralEOL turns CRLF line endings inside heredoc bodies and
+// quoted strings into LF
+func normalizeLiteralEOL(in []byte) []byte {
+	spans := literalSpans(in)
+	if len(spans) == 0 {
+		return in
+	}
+
+	var out []byte
+	last := 0
+	for _, s := range spans {
+		out = append(out, in[last:s.start]...)
+		out = append(out, bytes.ReplaceAll(in[s.start:s.end], []byte("\r\n"), []byte("\n"))...)
+		last = s.end
+	}
+	return append(out, in[last:]...)
+}
@@ -0,0 +1,89 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// heredocBody mixes lines ending in "}", runs of blank lines, text that
+// looks like a resource block and CRLF line endings
+const heredocBody = "if (x) {\n  y = ({ z })\n}\nresource \"a\" \"b\" {}\n}\n\n\n\nlast line\r\nwindows line\r\n}\r\n${var.name}\n"
+
+// TestLiteralsSurviveFormat verifies heredoc bodies and quoted strings
+// come through Format byte-identical
+func TestLiteralsSurviveFormat(t *testing.T) {
+	input := "locals {\n  script  = <<EOT\n" + heredocBody + "EOT\n  wrapped = \"({ x })\"\n}\n\n"
+
+	formatter := New(config.NewConfig())
+	formatted := string(formatter.Format([]byte(input)))
+
+	if !strings.Contains(formatted, "<<EOT\n"+heredocBody+"EOT\n") {
+		t.Errorf("heredoc body was modified:\n%q\nwant it to contain:\n%q", formatted, heredocBody)
+	}
+	if !strings.Contains(formatted, `"({ x })"`) {
+		t.Errorf("quoted string was modified:\n%q", formatted)
+	}
+	if formatted != input {
+		t.Errorf("Format() =\n%q\nwant unchanged:\n%q", formatted, input)
+	}
+}
+
+// TestNormalizeEOLInStrings verifies CRLF inside heredocs is only
+// rewritten when asked for
+func TestNormalizeEOLInStrings(t *testing.T) {
+	input := "locals {\n  script = <<EOT\none\r\ntwo\r\nEOT\n}\n\n"
+	tests := []struct {
+		name      string
+		normalize bool
+		expected  string
+	}{
+		{"default", false, input},
+		{"normalized", true, "locals {\n  script = <<EOT\none\ntwo\nEOT\n}\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.NormalizeEOLInStrings = tt.normalize
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() = %q, want %q", formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestPreserveBlankLinesInComments verifies blank lines inside a comment
+// are kept under the flag, while those between blocks still collapse
+func TestPreserveBlankLinesInComments(t *testing.T) {
+	input := "# Networking\n#\n# The VPC and its subnets.\n\n\n# Subnets are one per zone.\nresource \"aws_vpc\" \"main\" {}\n\n\n\nresource \"aws_subnet\" \"a\" {}\n"
+	tests := []struct {
+		name     string
+		preserve bool
+		expected string
+	}{
+		{
+			name:     "preserved",
+			preserve: true,
+			expected: "# Networking\n#\n# The VPC and its subnets.\n\n\n# Subnets are one per zone.\nresource \"aws_vpc\" \"main\" {}\n\nresource \"aws_subnet\" \"a\" {}\n\n",
+		},
+		{
+			name:     "collapsed",
+			expected: "# Networking\n#\n# The VPC and its subnets.\n\n# Subnets are one per zone.\nresource \"aws_vpc\" \"main\" {}\n\nresource \"aws_subnet\" \"a\" {}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.PreserveBlankLinesInComments = tt.preserve
+			if formatted := New(cfg).Format([]byte(input)); string(formatted) != tt.expected {
+				t.Errorf("Format() = %q, want %q", formatted, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package formatter
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// bodyItem is an attribute or nested block of a body, spanning from the
+// comment lines directly above it to the end of its last line
+type bodyItem struct {
+	name       string
+	block      *hclsyntax.Block // nil for attributes
+	start, end int
+}
+
+// bodyOrder decides the order of the items in the body of block, which is
+// nil for the top level of the file. It returns the items in their new
+// order, or nil to leave them as they are.
+type bodyOrder func(block *hclsyntax.Block, items []bodyItem) []bodyItem
+
+// reorderBodies rearranges the items of every body in src, at any nesting
+// depth, in the order chosen by order. Reordered items fill the positions
+// the original items had, so blank lines and standalone comments between
+// them stay where they are, while comments directly above an item move
+// with it. Blocks whose type is in frozen keep their content as written.
+func reorderBodies(src []byte, order bodyOrder, frozen []string) []byte {
+	file, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return src
+	}
+
+	var out bytes.Buffer
+	renderBody(&out, src, nil, body, 0, len(src), order, frozen)
+	return out.Bytes()
+}
+
+// bodyItems returns the items of body found within src[start:end], in
+// source order
+func bodyItems(src []byte, body *hclsyntax.Body, start, end int) []bodyItem {
+	items := make([]bodyItem, 0, len(body.Attributes)+len(body.Blocks))
+	add := func(name string, block *hclsyntax.Block, rng hcl.Range) {
+		item := bodyItem{name: name, block: block, start: rng.Start.Byte, end: rng.End.Byte}
+
+		// Only take whole lines when the item doesn't share its line with
+		// the braces of the enclosing block
+		if lineStart := bytes.LastIndexByte(src[:rng.Start.Byte], '\n') + 1; lineStart >= start {
+			item.start = max(leadCommentStart(src, rng.Start.Byte), start)
+		}
+		if nl := bytes.IndexByte(src[rng.End.Byte:end], '\n'); nl >= 0 {
+			item.end = rng.End.Byte + nl + 1
+		}
+		items = append(items, item)
+	}
+
+	for name, attr := range body.Attributes {
+		add(name, nil, attr.SrcRange)
+	}
+	for _, block := range body.Blocks {
+		add(block.Type, block, block.Range())
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].start < items[j].start })
+	return items
+}
+
+// renderBody writes src[start:end], which holds the items of body, with
+// the items put in the order chosen for block and their own bodies
+// rendered recursively
+func renderBody(out *bytes.Buffer, src []byte, block *hclsyntax.Block, body *hclsyntax.Body, start, end int, order bodyOrder, frozen []string) {
+	slots := bodyItems(src, body, start, end)
+	items := slots
+	if len(slots) > 1 {
+		if ordered := order(block, slots); ordered != nil {
+			items = ordered
+		}
+	}
+
+	last := start
+	for i, slot := range slots {
+		out.Write(src[last:slot.start])
+		renderItem(out, src, items[i], order, frozen)
+		// An item moved away from the end of the region may lack a newline
+		if items[i].end != slot.end && !bytes.HasSuffix(src[items[i].start:items[i].end], []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		last = slot.end
+	}
+	out.Write(src[last:end])
+}
+
+// renderItem writes a single item, rendering the body of a block recursively
+func renderItem(out *bytes.Buffer, src []byte, item bodyItem, order bodyOrder, frozen []string) {
+	if item.block == nil || slices.Contains(frozen, item.block.Type) {
+		out.Write(src[item.start:item.end])
+		return
+	}
+	bodyStart := item.block.OpenBraceRange.End.Byte
+	bodyEnd := item.block.CloseBraceRange.Start.Byte
+	out.Write(src[item.start:bodyStart])
+	renderBody(out, src, item.block, item.block.Body, bodyStart, bodyEnd, order, frozen)
+	out.Write(src[bodyEnd:item.end])
+}
+
+// rankedOrder returns a bodyOrder that stably sorts the items of blocks of
+// the given type by the rank of their names. Unranked items sort after
+// ranked attributes and before ranked blocks.
+func rankedOrder(blockType string, attrRanks, blockRanks map[string]int) bodyOrder {
+	rank := func(item bodyItem) int {
+		if item.block == nil {
+			if r, ok := attrRanks[item.name]; ok {
+				return r
+			}
+			return len(attrRanks)
+		}
+		if r, ok := blockRanks[item.name]; ok {
+			return len(attrRanks) + 1 + r
+		}
+		return len(attrRanks) + 1 + len(blockRanks)
+	}
+
+	return func(block *hclsyntax.Block, items []bodyItem) []bodyItem {
+		if block == nil || block.Type != blockType {
+			return nil
+		}
+		sorted := append([]bodyItem(nil), items...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return rank(sorted[i]) < rank(sorted[j])
+		})
+		return sorted
+	}
+}
+
+// keepInterleaving returns a bodyOrder that reorders items like order, but
+// keeps attributes where attributes were and nested blocks where blocks
+// were, so the attributes and blocks of a body stay interleaved as written
+func keepInterleaving(order bodyOrder) bodyOrder {
+	return func(block *hclsyntax.Block, items []bodyItem) []bodyItem {
+		ordered := order(block, items)
+		if ordered == nil {
+			return nil
+		}
+		var attrs, blocks []bodyItem
+		for _, item := range ordered {
+			if item.block == nil {
+				attrs = append(attrs, item)
+			} else {
+				blocks = append(blocks, item)
+			}
+		}
+		kept := make([]bodyItem, 0, len(items))
+		for _, slot := range items {
+			if slot.block == nil {
+				kept, attrs = append(kept, attrs[0]), attrs[1:]
+			} else {
+				kept, blocks = append(kept, blocks[0]), blocks[1:]
+			}
+		}
+		return kept
+	}
+}
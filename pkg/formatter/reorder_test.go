@@ -0,0 +1,350 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestCanonicalDynamic verifies dynamic blocks are reordered to
+// for_each, iterator, then content
+func TestCanonicalDynamic(t *testing.T) {
+	input := `resource "aws_security_group" "web" {
+  name = "web"
+
+  dynamic "ingress" {
+    content {
+      from_port = ingress.value.port
+      to_port   = ingress.value.port
+    }
+    # the rules to open
+    for_each = var.rules
+    iterator = ingress
+  }
+}
+`
+	tests := []struct {
+		name      string
+		canonical bool
+		expected  string
+	}{
+		{
+			name:      "reordered",
+			canonical: true,
+			expected: `resource "aws_security_group" "web" {
+  name = "web"
+
+  dynamic "ingress" {
+    # the rules to open
+    for_each = var.rules
+    iterator = ingress
+    content {
+      from_port = ingress.value.port
+      to_port   = ingress.value.port
+    }
+
+  }
+
+}
+
+`,
+		},
+		{
+			name:      "disabled",
+			canonical: false,
+			expected: `resource "aws_security_group" "web" {
+  name = "web"
+
+  dynamic "ingress" {
+    content {
+      from_port = ingress.value.port
+      to_port   = ingress.value.port
+    }
+
+    # the rules to open
+    for_each = var.rules
+    iterator = ingress
+  }
+
+}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.CanonicalDynamic = tt.canonical
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() with canonical-dynamic=%v =\n%s\nwant:\n%s", tt.canonical, formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestNoReorderTypes verifies exempt block types keep their position and
+// content order while other blocks are sorted
+func TestNoReorderTypes(t *testing.T) {
+	input := `variable "zone" {}
+
+terraform {
+  required_version = ">= 1.5"
+  experiments      = []
+}
+
+variable "account" {}
+
+resource "aws_instance" "web" {
+  count = 1
+  ami   = "ami-123"
+
+  dynamic "ebs_block_device" {
+    for_each = var.volumes
+    content {
+      volume_size = 20
+      device_name = "/dev/sdb"
+    }
+  }
+}
+
+`
+	tests := []struct {
+		name     string
+		exempt   []string
+		expected string
+	}{
+		{
+			name: "nothing exempt",
+			expected: `variable "account" {}
+
+terraform {
+  required_version = ">= 1.5"
+  experiments      = []
+}
+
+variable "zone" {}
+
+resource "aws_instance" "web" {
+  ami   = "ami-123"
+  count = 1
+
+  dynamic "ebs_block_device" {
+    for_each = var.volumes
+    content {
+      device_name = "/dev/sdb"
+      volume_size = 20
+    }
+
+  }
+
+}
+
+`,
+		},
+		{
+			name:   "dynamic exempt",
+			exempt: []string{"dynamic"},
+			expected: `variable "account" {}
+
+terraform {
+  required_version = ">= 1.5"
+  experiments      = []
+}
+
+variable "zone" {}
+
+resource "aws_instance" "web" {
+  ami   = "ami-123"
+  count = 1
+
+  dynamic "ebs_block_device" {
+    for_each = var.volumes
+    content {
+      volume_size = 20
+      device_name = "/dev/sdb"
+    }
+
+  }
+
+}
+
+`,
+		},
+		{
+			name:   "variables and resources exempt",
+			exempt: []string{"variable", "resource"},
+			expected: `variable "zone" {}
+
+terraform {
+  required_version = ">= 1.5"
+  experiments      = []
+}
+
+variable "account" {}
+
+resource "aws_instance" "web" {
+  count = 1
+  ami   = "ami-123"
+
+  dynamic "ebs_block_device" {
+    for_each = var.volumes
+    content {
+      volume_size = 20
+      device_name = "/dev/sdb"
+    }
+
+  }
+
+}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.SortVars = true
+			cfg.SortInputs = true
+			cfg.NoReorderTypes = tt.exempt
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() with no-reorder-types=%v =\n%s\nwant:\n%s", tt.exempt, formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestPreserveInterleaving verifies attributes and nested blocks stay
+// interleaved as written, both when nothing reorders them and when
+// -preserve-interleaving constrains -canonical-dynamic
+func TestPreserveInterleaving(t *testing.T) {
+	input := `resource "aws_security_group" "web" {
+  name = "web"
+  ingress {
+    from_port = 80
+  }
+
+  description = "web"
+  dynamic "egress" {
+    iterator = rule
+    content {
+      to_port = rule.value
+    }
+
+    for_each = var.rules
+  }
+
+}
+`
+	tests := []struct {
+		name      string
+		canonical bool
+		expected  string
+	}{
+		{
+			name:     "not reordered",
+			expected: input + "\n",
+		},
+		{
+			name:      "canonical dynamic",
+			canonical: true,
+			expected: `resource "aws_security_group" "web" {
+  name = "web"
+  ingress {
+    from_port = 80
+  }
+
+  description = "web"
+  dynamic "egress" {
+    for_each = var.rules
+    content {
+      to_port = rule.value
+    }
+
+    iterator = rule
+  }
+
+}
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.PreserveInterleaving = true
+			cfg.CanonicalDynamic = tt.canonical
+			formatted := New(cfg).Format([]byte(input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() =\n%s\nwant:\n%s", formatted, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCanonicalLifecycle verifies a scrambled lifecycle block is put in
+// the conventional order, keeping list contents and condition blocks whole
+func TestCanonicalLifecycle(t *testing.T) {
+	input := `resource "aws_instance" "web" {
+  ami = "ami-12345"
+
+  lifecycle {
+    postcondition {
+      condition     = self.public_ip != ""
+      error_message = "no public ip"
+    }
+    ignore_changes = [
+      tags,  # managed elsewhere
+      ami,
+    ]
+    precondition {
+      condition     = var.enabled
+      error_message = "disabled"
+    }
+    replace_triggered_by = [terraform_data.version]
+    prevent_destroy       = true
+    create_before_destroy = true
+  }
+}
+`
+	expected := `resource "aws_instance" "web" {
+  ami = "ami-12345"
+
+  lifecycle {
+    create_before_destroy = true
+    prevent_destroy       = true
+    ignore_changes = [
+      tags, # managed elsewhere
+      ami,
+    ]
+    replace_triggered_by = [terraform_data.version]
+    precondition {
+      condition     = var.enabled
+      error_message = "disabled"
+    }
+
+    postcondition {
+      condition     = self.public_ip != ""
+      error_message = "no public ip"
+    }
+
+  }
+
+}
+
+`
+	cfg := config.NewConfig()
+	cfg.CanonicalLifecycle = true
+	formatted := New(cfg).Format([]byte(input))
+	if string(formatted) != expected {
+		t.Errorf("Format() with canonical-lifecycle =\n%s\nwant:\n%s", formatted, expected)
+	}
+}
@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// rewriteTokens lexes in and replaces the source bytes of every token for
+// which fn returns a replacement, leaving everything else byte-identical.
+// Content that cannot be lexed is returned unchanged.
+func rewriteTokens(in []byte, fn func(tok hclsyntax.Token) ([]byte, bool)) []byte {
+	tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return in
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, tok := range tokens {
+		replacement, ok := fn(tok)
+		if !ok {
+			continue
+		}
+		out.Write(in[last:tok.Range.Start.Byte])
+		out.Write(replacement)
+		last = tok.Range.End.Byte
+	}
+	if last == 0 {
+		return in
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
+
+// normalizeComments rewrites line comments to use the marker for style,
+// "hash" for # or "slash" for //. Block comments are left alone.
+func normalizeComments(in []byte, style string) []byte {
+	var from, to []byte
+	switch style {
+	case "hash":
+		from, to = []byte("//"), []byte("#")
+	case "slash":
+		from, to = []byte("#"), []byte("//")
+	default:
+		return in
+	}
+
+	return rewriteTokens(in, func(tok hclsyntax.Token) ([]byte, bool) {
+		if tok.Type != hclsyntax.TokenComment || !bytes.HasPrefix(tok.Bytes, from) {
+			return nil, false
+		}
+		return append(append([]byte{}, to...), tok.Bytes[len(from):]...), true
+	})
+}
@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestCommentStyle verifies line comments are normalized without touching
+// strings, heredocs or block comments
+func TestCommentStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    string
+		input    string
+		expected string
+	}{
+		{
+			name:     "slash to hash",
+			style:    "hash",
+			input:    "// leading\nresource \"a\" \"b\" {\n  foo = bar // trailing\n}\n",
+			expected: "# leading\nresource \"a\" \"b\" {\n  foo = bar # trailing\n}\n\n",
+		},
+		{
+			name:     "hash to slash",
+			style:    "slash",
+			input:    "# leading\nresource \"a\" \"b\" {\n  foo = bar # trailing\n}\n",
+			expected: "// leading\nresource \"a\" \"b\" {\n  foo = bar // trailing\n}\n\n",
+		},
+		{
+			name:     "markers inside strings and heredocs untouched",
+			style:    "hash",
+			input:    "locals {\n  url = \"https://example.com\"\n  doc = <<EOT\n// not a comment\nEOT\n}\n",
+			expected: "locals {\n  url = \"https://example.com\"\n  doc = <<EOT\n// not a comment\nEOT\n}\n\n",
+		},
+		{
+			name:     "block comments untouched",
+			style:    "hash",
+			input:    "/* block */\nfoo = bar\n",
+			expected: "/* block */\nfoo = bar\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.CommentStyle = tt.style
+			formatter := New(cfg)
+
+			formatted := formatter.Format([]byte(tt.input))
+			if string(formatted) != tt.expected {
+				t.Errorf("Format() with comment-style=%s = %q, want %q", tt.style, formatted, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package formatter
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Verify checks that formatted, the result of formatting content, still
+// parses, guarding against formatting rules that corrupt their input.
+// Content that didn't parse to begin with isn't held against the rules.
+func Verify(content, formatted []byte) error {
+	_, diags := hclwrite.ParseConfig(formatted, "", hcl.InitialPos)
+	if !diags.HasErrors() {
+		return nil
+	}
+	if _, orig := hclwrite.ParseConfig(content, "", hcl.InitialPos); orig.HasErrors() {
+		return nil
+	}
+	return fmt.Errorf("formatted output is not valid HCL: %w", diags)
+}
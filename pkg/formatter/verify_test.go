@@ -0,0 +1,27 @@
+package formatter
+
+import "testing"
+
+// TestVerify verifies output that no longer parses is reported, unless the
+// input didn't parse either
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		formatted string
+		wantErr   bool
+	}{
+		{"valid output", "a=1\n", "a = 1\n", false},
+		{"corrupted output", "a = { b = 1 }\n", "a = { b = 1\n", true},
+		{"invalid input", "a = {\n", "a = {\n\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify([]byte(tt.content), []byte(tt.formatted))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package formatter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// reVersionConstraint matches a single version constraint, an optional
+// operator followed by a version
+var reVersionConstraint = regexp.MustCompile(`^\s*(!=|>=|<=|~>|=|>|<)?\s*(v?[0-9]+(?:\.[0-9]+)*(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)\s*$`)
+
+// normalizeProviderVersions rewrites the version constraints of the
+// providers in terraform required_providers blocks to the canonical
+// ">= 1.0, < 2.0" style: one space between operator and version, and ", "
+// between constraints. Both the version of a provider object and the older
+// bare string form are rewritten. Constraints that don't parse, or that
+// hold escapes or interpolations, are left alone.
+func normalizeProviderVersions(in []byte) []byte {
+	body := parseBody(in)
+	if body == nil {
+		return in
+	}
+
+	// Collect the literals holding constraints, by where they start
+	versions := map[int]bool{}
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		for _, providers := range block.Body.Blocks {
+			if providers.Type != "required_providers" {
+				continue
+			}
+			for _, attr := range providers.Body.Attributes {
+				expr := attr.Expr
+				if obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr); ok {
+					expr = nil
+					for _, item := range obj.Items {
+						if objectKeyName(item.KeyExpr) == "version" {
+							expr = item.ValueExpr
+						}
+					}
+				}
+				if tmpl, ok := expr.(*hclsyntax.TemplateExpr); ok && len(tmpl.Parts) == 1 {
+					versions[tmpl.Parts[0].Range().Start.Byte] = true
+				}
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return in
+	}
+
+	return rewriteTokens(in, func(tok hclsyntax.Token) ([]byte, bool) {
+		if tok.Type != hclsyntax.TokenQuotedLit || !versions[tok.Range.Start.Byte] {
+			return nil, false
+		}
+		constraint, ok := canonicalConstraint(string(tok.Bytes))
+		if !ok || constraint == string(tok.Bytes) {
+			return nil, false
+		}
+		return []byte(constraint), true
+	})
+}
+
+// canonicalConstraint returns the canonical form of a comma-separated list
+// of version constraints, reporting false when it isn't one
+func canonicalConstraint(s string) (string, bool) {
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		m := reVersionConstraint.FindStringSubmatch(part)
+		if m == nil {
+			return "", false
+		}
+		parts[i] = strings.TrimSpace(m[1] + " " + m[2])
+	}
+	return strings.Join(parts, ", "), true
+}
+
+// objectKeyName returns the name of an object key written as a bare
+// identifier or a plain string, or "" for any other key
+func objectKeyName(expr hclsyntax.Expression) string {
+	if key, ok := expr.(*hclsyntax.ObjectConsKeyExpr); ok {
+		expr = key.Wrapped
+	}
+	switch e := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		if len(e.Traversal) == 1 {
+			return e.Traversal.RootName()
+		}
+	case *hclsyntax.TemplateExpr:
+		if len(e.Parts) != 1 {
+			break
+		}
+		if lit, ok := e.Parts[0].(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+			return lit.Val.AsString()
+		}
+	}
+	return ""
+}
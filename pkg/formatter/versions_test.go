@@ -0,0 +1,94 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestNormalizeProviderVersions verifies the version constraints of
+// required_providers are respaced while other strings are left alone
+func TestNormalizeProviderVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "provider objects",
+			input: `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~>5.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = ">=3.1 ,<  4.0.0"
+    }
+    null = {
+      "version" = "  3.2.1 "
+    }
+  }
+}
+`,
+			expected: `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = ">= 3.1, < 4.0.0"
+    }
+    null = {
+      "version" = "3.2.1"
+    }
+  }
+}
+`,
+		},
+		{
+			name:     "bare string versions",
+			input:    "terraform {\n  required_providers {\n    aws = \"!=4.1,~>4\"\n  }\n}\n",
+			expected: "terraform {\n  required_providers {\n    aws = \"!= 4.1, ~> 4\"\n  }\n}\n",
+		},
+		{
+			name:     "already canonical",
+			input:    "terraform {\n  required_providers {\n    aws = {\n      version = \">= 1.0, < 2.0\"\n    }\n  }\n}\n",
+			expected: "terraform {\n  required_providers {\n    aws = {\n      version = \">= 1.0, < 2.0\"\n    }\n  }\n}\n",
+		},
+		{
+			name:     "not constraints",
+			input:    "terraform {\n  required_providers {\n    aws = {\n      version = \"~>${var.v}\"\n      source  = \"~>1\"\n    }\n    gcp = { version = \"latest\" }\n  }\n}\n",
+			expected: "terraform {\n  required_providers {\n    aws = {\n      version = \"~>${var.v}\"\n      source  = \"~>1\"\n    }\n    gcp = { version = \"latest\" }\n  }\n}\n",
+		},
+		{
+			name:     "outside required_providers",
+			input:    "terraform {\n  required_version = \">=1.5\"\n}\n\nmodule \"vpc\" {\n  version = \"~>5.0\"\n}\n",
+			expected: "terraform {\n  required_version = \">=1.5\"\n}\n\nmodule \"vpc\" {\n  version = \"~>5.0\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeProviderVersions([]byte(tt.input)); string(got) != tt.expected {
+				t.Errorf("normalizeProviderVersions() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestProviderVersionsPass verifies the pass only runs when enabled
+func TestProviderVersionsPass(t *testing.T) {
+	input := "terraform {\n  required_providers {\n    aws = \"~>5\"\n  }\n}\n"
+	for _, enabled := range []bool{false, true} {
+		cfg := config.NewConfig()
+		cfg.NormalizeProviderVersions = enabled
+		_, stats := New(cfg).FormatStats([]byte(input))
+		if got := stats["provider_versions"] > 0; got != enabled {
+			t.Errorf("enabled=%v: provider_versions fired = %v", enabled, got)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package formatter
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// wrapCalls returns a pass that puts each argument of a function call on
+// its own line when the line holding the call is wider than width columns.
+// Only the first call written on a single line is wrapped, so calls nested
+// in its arguments stay inline, and calls inside string templates, with a
+// trailing comma or with expanded arguments are left alone.
+func wrapCalls(width int) func([]byte) []byte {
+	return func(in []byte) []byte {
+		tokens, diags := hclsyntax.LexConfig(in, "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return in
+		}
+
+		// Offsets at which a newline is inserted, and ranges of spaces
+		// that it replaces
+		type cut struct{ start, end int }
+		var cuts []cut
+
+		templates := 0
+		wrappedLine := -1
+		for i := 0; i < len(tokens); i++ {
+			tok := tokens[i]
+			switch tok.Type {
+			case hclsyntax.TokenOQuote, hclsyntax.TokenOHeredoc:
+				templates++
+				continue
+			case hclsyntax.TokenCQuote, hclsyntax.TokenCHeredoc:
+				templates--
+				continue
+			}
+			if templates > 0 || tok.Type != hclsyntax.TokenIdent || tok.Range.Start.Line == wrappedLine {
+				continue
+			}
+			if i+1 >= len(tokens) || tokens[i+1].Type != hclsyntax.TokenOParen {
+				continue
+			}
+			if lineWidth(in, tok.Range.Start.Byte) <= width {
+				continue
+			}
+
+			args, closing, ok := callArgs(tokens, i+1)
+			if !ok || !singleLine(args) || tokens[closing-1].Type == hclsyntax.TokenComma {
+				continue
+			}
+			open := tokens[i+1].Range.End.Byte
+			cuts = append(cuts, cut{open, open})
+			for _, arg := range args[1:] {
+				start := arg[0].Range.Start.Byte
+				comma := bytes.LastIndexByte(in[:start], ',') + 1
+				cuts = append(cuts, cut{comma, start})
+			}
+			cuts = append(cuts, cut{tokens[closing-1].Range.End.Byte, tokens[closing].Range.Start.Byte})
+			wrappedLine = tok.Range.Start.Line
+			i = closing
+		}
+		if len(cuts) == 0 {
+			return in
+		}
+
+		var out bytes.Buffer
+		last := 0
+		for _, c := range cuts {
+			out.Write(in[last:c.start])
+			out.WriteByte('\n')
+			last = c.end
+		}
+		out.Write(in[last:])
+
+		// Let canonical formatting indent the arguments
+		return hclwrite.Format(out.Bytes())
+	}
+}
+
+// lineWidth returns the number of columns of the line holding offset
+func lineWidth(src []byte, offset int) int {
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := len(src)
+	if nl := bytes.IndexByte(src[offset:], '\n'); nl >= 0 {
+		end = offset + nl
+	}
+	return utf8.RuneCount(src[start:end])
+}
+
+// singleLine reports whether the arguments of a call can be wrapped: they
+// hold no newlines or comments and there is at least one of them
+func singleLine(args []hclsyntax.Tokens) bool {
+	for _, arg := range args {
+		for _, tok := range arg {
+			if tok.Type == hclsyntax.TokenNewline || tok.Type == hclsyntax.TokenComment {
+				return false
+			}
+		}
+	}
+	return len(args) > 0
+}
@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/krewenki/tffmt/pkg/config"
+)
+
+// TestWrapCalls verifies function calls on lines wider than the limit get
+// one argument per line, while short and nested calls stay inline
+func TestWrapCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "short call",
+			input:    "a = merge(var.tags, local.tags)\n",
+			expected: "a = merge(var.tags, local.tags)\n\n",
+		},
+		{
+			name:  "long call",
+			input: "policy = merge(local.statements, { Version = \"2012-10-17\" }, var.extra_statements)\n",
+			expected: `policy = merge(
+  local.statements,
+  { Version = "2012-10-17" },
+  var.extra_statements
+)
+
+`,
+		},
+		{
+			name:  "nested calls stay inline",
+			input: "resource \"a\" \"b\" {\n  name = format(\"%s-%s-%s\", lower(var.project_name), lower(var.environment), var.suffix)\n}\n",
+			expected: `resource "a" "b" {
+  name = format(
+    "%s-%s-%s",
+    lower(var.project_name),
+    lower(var.environment),
+    var.suffix
+  )
+}
+
+`,
+		},
+		{
+			name:     "call already spanning lines",
+			input:    "a = merge(\n  var.tags, local.tags, local.a_very_long_name_for_this_thing)\n",
+			expected: "a = merge(\nvar.tags, local.tags, local.a_very_long_name_for_this_thing)\n\n",
+		},
+		{
+			name:     "call inside a string template",
+			input:    "a = \"${join(\"-\", [var.project_name, var.environment, var.region, var.suffix])}\"\n",
+			expected: "a = \"${join(\"-\", [var.project_name, var.environment, var.region, var.suffix])}\"\n\n",
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.WrapCalls = 40
+	f := New(cfg)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.Format([]byte(tt.input))
+			if string(got) != tt.expected {
+				t.Errorf("Format() = %q, want %q", got, tt.expected)
+			}
+			if again := f.Format(got); string(again) != string(got) {
+				t.Errorf("Format() is not idempotent: %q", again)
+			}
+		})
+	}
+}
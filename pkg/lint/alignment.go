@@ -0,0 +1,47 @@
+package lint
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// CheckAlignment reports lines whose alignment depends on the tab width:
+// lines indented with both tabs and spaces, and lines using a tab after
+// code to line something up. Tabs inside strings, heredocs and comments are
+// part of their content and not checked. The issues are warnings, for
+// teams moving to space indentation before enforcing it.
+func CheckAlignment(content []byte, filename string) []Issue {
+	tokens, diags := hclsyntax.LexConfig(content, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+
+	var issues []Issue
+	reported := map[int]bool{}
+	lineStart, prevEnd := true, 0
+	for _, tok := range tokens {
+		gap := content[prevEnd:tok.Range.Start.Byte]
+		if line := tok.Range.Start.Line; bytes.IndexByte(gap, '\t') >= 0 && !reported[line] {
+			message := "tab after code aligns differently at other tab widths"
+			if lineStart {
+				message = "indentation mixes tabs and spaces, so its alignment depends on the tab width"
+			}
+			if !lineStart || bytes.IndexByte(gap, ' ') >= 0 {
+				issues = append(issues, Issue{
+					Range:   hcl.Range{Filename: filename, Start: tok.Range.Start, End: tok.Range.Start},
+					Message: message,
+					Warning: true,
+				})
+				reported[line] = true
+			}
+		}
+
+		// Line comments hold their newline
+		lineStart = tok.Type == hclsyntax.TokenNewline ||
+			tok.Type == hclsyntax.TokenComment && bytes.HasSuffix(tok.Bytes, []byte("\n"))
+		prevEnd = tok.Range.End.Byte
+	}
+	return issues
+}
@@ -0,0 +1,52 @@
+package lint
+
+import "testing"
+
+func TestCheckAlignment(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "tab aligned",
+			input: "resource \"aws_instance\" \"web\" {\n  ami =\t\t\"ami-12345\"\n  instance_type = \"t2.micro\"\n}\n",
+			expected: []string{
+				"Warning: main.tf:2: tab after code aligns differently at other tab widths",
+			},
+		},
+		{
+			name:  "mixed indentation",
+			input: "resource \"aws_instance\" \"web\" {\n\t  ami = \"ami-12345\"\n}\n",
+			expected: []string{
+				"Warning: main.tf:2: indentation mixes tabs and spaces, so its alignment depends on the tab width",
+			},
+		},
+		{
+			name:  "space aligned",
+			input: "resource \"aws_instance\" \"web\" {\n  ami           = \"ami-12345\"\n  instance_type = \"t2.micro\"\n}\n",
+		},
+		{
+			name:  "tab indented",
+			input: "resource \"aws_instance\" \"web\" {\n\tami = \"ami-12345\"\n}\n",
+		},
+		{
+			name:  "tabs in values and comments",
+			input: "locals {\n  a = \"x\ty\" # a\tb\n  b = <<-EOT\n\t\tindented\n  EOT\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := CheckAlignment([]byte(tt.input), "main.tf")
+			if len(issues) != len(tt.expected) {
+				t.Fatalf("CheckAlignment() = %v, want %v", issues, tt.expected)
+			}
+			for i, issue := range issues {
+				if issue.String() != tt.expected[i] {
+					t.Errorf("CheckAlignment()[%d] = %q, want %q", i, issue.String(), tt.expected[i])
+				}
+			}
+		})
+	}
+}
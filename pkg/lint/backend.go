@@ -0,0 +1,79 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// backendKeys lists the configuration arguments accepted by each known
+// backend type. Backends not listed here are not checked.
+var backendKeys = map[string]map[string]bool{
+	"s3": keySet(
+		"access_key", "acl", "allowed_account_ids", "assume_role",
+		"assume_role_duration_seconds", "assume_role_policy", "assume_role_policy_arns",
+		"assume_role_tags", "assume_role_transitive_tag_keys", "assume_role_with_web_identity",
+		"bucket", "custom_ca_bundle", "dynamodb_endpoint", "dynamodb_table",
+		"ec2_metadata_service_endpoint", "ec2_metadata_service_endpoint_mode", "encrypt",
+		"endpoint", "endpoints", "external_id", "forbidden_account_ids", "force_path_style",
+		"http_proxy", "https_proxy", "iam_endpoint", "insecure", "key", "kms_key_id",
+		"max_retries", "no_proxy", "profile", "region", "retry_mode", "role_arn",
+		"secret_key", "session_name", "shared_config_files", "shared_credentials_file",
+		"shared_credentials_files", "skip_credentials_validation", "skip_metadata_api_check",
+		"skip_region_validation", "skip_requesting_account_id", "skip_s3_checksum",
+		"sse_customer_key", "sts_endpoint", "sts_region", "token", "use_dualstack_endpoint",
+		"use_fips_endpoint", "use_legacy_workflow", "use_lockfile", "use_path_style",
+		"workspace_key_prefix",
+	),
+}
+
+// keySet builds a lookup set from a list of keys
+func keySet(keys ...string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// CheckBackend reports arguments of terraform backend blocks that are not
+// accepted by the backend type, for the backend types it knows about
+func CheckBackend(body *hclsyntax.Body) []Issue {
+	var issues []Issue
+	for _, tf := range body.Blocks {
+		if tf.Type != "terraform" {
+			continue
+		}
+		for _, backend := range tf.Body.Blocks {
+			if backend.Type != "backend" || len(backend.Labels) != 1 {
+				continue
+			}
+			known, ok := backendKeys[backend.Labels[0]]
+			if !ok {
+				continue
+			}
+
+			report := func(kind, name string, rng hcl.Range) {
+				if !known[name] {
+					issues = append(issues, Issue{
+						Range:   rng,
+						Message: fmt.Sprintf("unknown %s %q in %s backend", kind, name, backend.Labels[0]),
+					})
+				}
+			}
+			for name, attr := range backend.Body.Attributes {
+				report("argument", name, attr.NameRange)
+			}
+			for _, block := range backend.Body.Blocks {
+				report("block", block.Type, block.TypeRange)
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Range.Start.Byte < issues[j].Range.Start.Byte
+	})
+	return issues
+}
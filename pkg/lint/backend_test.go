@@ -0,0 +1,69 @@
+package lint
+
+import "testing"
+
+func TestCheckBackend(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "valid s3 backend",
+			input: `terraform {
+  backend "s3" {
+    bucket = "state"
+    key    = "app/terraform.tfstate"
+    region = "us-east-1"
+
+    assume_role {
+      role_arn = "arn:aws:iam::123456789012:role/state"
+    }
+  }
+}
+`,
+			expected: nil,
+		},
+		{
+			name: "unknown key in s3 backend",
+			input: `terraform {
+  backend "s3" {
+    bucket    = "state"
+    key       = "app/terraform.tfstate"
+    container = "tfstate"
+  }
+}
+`,
+			expected: []string{`backend.tf:5: unknown argument "container" in s3 backend`},
+		},
+		{
+			name: "unknown backend type not checked",
+			input: `terraform {
+  backend "custom" {
+    anything = true
+  }
+}
+`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := Parse([]byte(tt.input), "backend.tf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			issues := CheckBackend(body)
+			if len(issues) != len(tt.expected) {
+				t.Fatalf("CheckBackend() = %v, want %v", issues, tt.expected)
+			}
+			for i, issue := range issues {
+				if issue.String() != tt.expected[i] {
+					t.Errorf("CheckBackend()[%d] = %q, want %q", i, issue.String(), tt.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package lint provides read-only checks that report problems in
+// terraform files without rewriting them.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Issue is a single problem reported by a check
+type Issue struct {
+	Range   hcl.Range
+	Message string
+
+	// Warning is set for issues that may well be intended, which are
+	// reported without failing the run
+	Warning bool
+}
+
+// String formats the issue as "file:line: message", or as
+// "Warning: file:line: message" for warnings
+func (i Issue) String() string {
+	s := fmt.Sprintf("%s:%d: %s", i.Range.Filename, i.Range.Start.Line, i.Message)
+	if i.Warning {
+		return "Warning: " + s
+	}
+	return s
+}
+
+// Failing reports whether any of issues isn't a warning
+func Failing(issues []Issue) bool {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses a terraform file into the body the checks inspect
+func Parse(content []byte, filename string) (*hclsyntax.Body, error) {
+	file, diags := hclsyntax.ParseConfig(content, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("%s: unexpected body type %T", filename, file.Body)
+	}
+	return body, nil
+}
+
+// CheckNaming reports resource and data blocks whose type or name labels
+// do not match pattern
+func CheckNaming(body *hclsyntax.Body, pattern *regexp.Regexp) []Issue {
+	var issues []Issue
+	for _, block := range body.Blocks {
+		if block.Type != "resource" && block.Type != "data" {
+			continue
+		}
+		kinds := []string{"type", "name"}
+		for i, label := range block.Labels {
+			if i >= len(kinds) || pattern.MatchString(label) {
+				continue
+			}
+			issues = append(issues, Issue{
+				Range:   block.LabelRanges[i],
+				Message: fmt.Sprintf("%s %s %q does not match %s", block.Type, kinds[i], label, pattern),
+			})
+		}
+	}
+	return issues
+}
+
+// CheckEmptyDefaults warns about variables defaulting to the empty string,
+// which is easily meant to be no default, making the variable required,
+// or null
+func CheckEmptyDefaults(body *hclsyntax.Body) []Issue {
+	var issues []Issue
+	for _, block := range body.Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 {
+			continue
+		}
+		attr, ok := block.Body.Attributes["default"]
+		if !ok {
+			continue
+		}
+		tmpl, ok := attr.Expr.(*hclsyntax.TemplateExpr)
+		if !ok || len(tmpl.Parts) > 1 {
+			continue
+		}
+		if len(tmpl.Parts) == 1 {
+			lit, ok := tmpl.Parts[0].(*hclsyntax.LiteralValueExpr)
+			if !ok || lit.Val.Type() != cty.String || lit.Val.AsString() != "" {
+				continue
+			}
+		}
+		issues = append(issues, Issue{
+			Range:   attr.SrcRange,
+			Message: fmt.Sprintf("variable %q defaults to \"\": did you mean no default, or null?", block.Labels[0]),
+			Warning: true,
+		})
+	}
+	return issues
+}
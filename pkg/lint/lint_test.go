@@ -0,0 +1,90 @@
+package lint
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestCheckNaming(t *testing.T) {
+	pattern := regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "compliant names",
+			input:    "resource \"aws_instance\" \"web_server\" {}\ndata \"aws_ami\" \"ubuntu\" {}\n",
+			expected: nil,
+		},
+		{
+			name:     "resource name not snake_case",
+			input:    "resource \"aws_instance\" \"WebServer\" {}\n",
+			expected: []string{`main.tf:1: resource name "WebServer" does not match ^[a-z][a-z0-9_]*$`},
+		},
+		{
+			name:     "data type not snake_case",
+			input:    "variable \"Region\" {}\n\ndata \"aws-ami\" \"ubuntu\" {}\n",
+			expected: []string{`main.tf:3: data type "aws-ami" does not match ^[a-z][a-z0-9_]*$`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := Parse([]byte(tt.input), "main.tf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			issues := CheckNaming(body, pattern)
+			if len(issues) != len(tt.expected) {
+				t.Fatalf("CheckNaming() = %v, want %v", issues, tt.expected)
+			}
+			for i, issue := range issues {
+				if issue.String() != tt.expected[i] {
+					t.Errorf("CheckNaming()[%d] = %q, want %q", i, issue.String(), tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckEmptyDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "empty string default",
+			input:    "variable \"prefix\" {\n  type    = string\n  default = \"\"\n}\n",
+			expected: []string{`Warning: main.tf:3: variable "prefix" defaults to "": did you mean no default, or null?`},
+		},
+		{
+			name:  "other defaults",
+			input: "variable \"a\" {\n  default = \"x\"\n}\n\nvariable \"b\" {\n  default = null\n}\n\nvariable \"c\" {}\n\nvariable \"d\" {\n  default = \"${var.x}\"\n}\n\nvariable \"e\" {\n  default = [\"\"]\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := Parse([]byte(tt.input), "main.tf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			issues := CheckEmptyDefaults(body)
+			if len(issues) != len(tt.expected) {
+				t.Fatalf("CheckEmptyDefaults() = %v, want %v", issues, tt.expected)
+			}
+			for i, issue := range issues {
+				if issue.String() != tt.expected[i] {
+					t.Errorf("CheckEmptyDefaults()[%d] = %q, want %q", i, issue.String(), tt.expected[i])
+				}
+			}
+			if Failing(issues) {
+				t.Errorf("Failing(%v) = true, want warnings only", issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ProviderAliases returns the "<type>.<alias>" names of the aliased
+// provider blocks declared in body, and of the aliases a module expects
+// through configuration_aliases in required_providers
+func ProviderAliases(body *hclsyntax.Body) []string {
+	var aliases []string
+	for _, block := range body.Blocks {
+		if block.Type == "terraform" {
+			aliases = append(aliases, configurationAliases(block.Body)...)
+			continue
+		}
+		if block.Type != "provider" || len(block.Labels) != 1 {
+			continue
+		}
+		attr, ok := block.Body.Attributes["alias"]
+		if !ok {
+			continue
+		}
+		alias, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || alias.IsNull() || !alias.IsKnown() || alias.Type() != cty.String {
+			continue
+		}
+		aliases = append(aliases, block.Labels[0]+"."+alias.AsString())
+	}
+	return aliases
+}
+
+// configurationAliases returns the provider references listed in the
+// configuration_aliases of the required_providers blocks of a terraform
+// block's body
+func configurationAliases(body *hclsyntax.Body) []string {
+	var aliases []string
+	for _, block := range body.Blocks {
+		if block.Type != "required_providers" {
+			continue
+		}
+		for _, attr := range block.Body.Attributes {
+			obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+			if !ok {
+				continue
+			}
+			for _, item := range obj.Items {
+				if hcl.ExprAsKeyword(item.KeyExpr) != "configuration_aliases" {
+					continue
+				}
+				tuple, ok := item.ValueExpr.(*hclsyntax.TupleConsExpr)
+				if !ok {
+					continue
+				}
+				for _, expr := range tuple.Exprs {
+					if ref, ok := providerRef(expr); ok {
+						aliases = append(aliases, ref)
+					}
+				}
+			}
+		}
+	}
+	return aliases
+}
+
+// CheckProviderRefs reports provider arguments of resource and data blocks
+// that refer to a "<type>.<alias>" provider not in declared
+func CheckProviderRefs(body *hclsyntax.Body, declared map[string]bool) []Issue {
+	var issues []Issue
+	for _, block := range body.Blocks {
+		if block.Type != "resource" && block.Type != "data" {
+			continue
+		}
+		attr, ok := block.Body.Attributes["provider"]
+		if !ok {
+			continue
+		}
+		ref, ok := providerRef(attr.Expr)
+		if !ok || declared[ref] {
+			continue
+		}
+		issues = append(issues, Issue{
+			Range:   attr.Expr.Range(),
+			Message: fmt.Sprintf("%s %s refers to undeclared provider %s", block.Type, strings.Join(block.Labels, "."), ref),
+		})
+	}
+	return issues
+}
+
+// providerRef returns the "<type>.<alias>" reference expr makes, if it is one
+func providerRef(expr hclsyntax.Expression) (string, bool) {
+	traversal, diags := hcl.AbsTraversalForExpr(expr)
+	if diags.HasErrors() || len(traversal) != 2 {
+		return "", false
+	}
+	attr, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", false
+	}
+	return traversal.RootName() + "." + attr.Name, true
+}
@@ -0,0 +1,93 @@
+package lint
+
+import "testing"
+
+func TestCheckProviderRefs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "declared alias",
+			input: `provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+resource "aws_instance" "web" {
+  provider = aws.west
+}
+`,
+			expected: nil,
+		},
+		{
+			name: "undeclared alias",
+			input: `provider "aws" {
+  alias = "west"
+}
+
+resource "aws_instance" "web" {
+  provider = aws.old
+}
+
+data "aws_ami" "base" {
+  provider = aws.west
+}
+`,
+			expected: []string{`providers.tf:6: resource aws_instance.web refers to undeclared provider aws.old`},
+		},
+		{
+			name: "configuration aliases",
+			input: `terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = [aws.east, aws.west]
+    }
+  }
+}
+
+resource "aws_instance" "web" {
+  provider = aws.east
+}
+
+resource "aws_instance" "db" {
+  provider = aws.north
+}
+`,
+			expected: []string{`providers.tf:15: resource aws_instance.db refers to undeclared provider aws.north`},
+		},
+		{
+			name: "default provider not checked",
+			input: `resource "aws_instance" "web" {
+  provider = aws
+}
+`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := Parse([]byte(tt.input), "providers.tf")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			declared := map[string]bool{}
+			for _, alias := range ProviderAliases(body) {
+				declared[alias] = true
+			}
+			issues := CheckProviderRefs(body, declared)
+			if len(issues) != len(tt.expected) {
+				t.Fatalf("CheckProviderRefs() = %v, want %v", issues, tt.expected)
+			}
+			for i, issue := range issues {
+				if issue.String() != tt.expected[i] {
+					t.Errorf("CheckProviderRefs()[%d] = %q, want %q", i, issue.String(), tt.expected[i])
+				}
+			}
+		})
+	}
+}